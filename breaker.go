@@ -0,0 +1,77 @@
+package skald
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit
+// breaker set up with WithCircuitBreaker is open
+var ErrCircuitOpen = errors.New("skald: circuit breaker open")
+
+// defaultBreakerWindow is the window within which failures must occur to trip
+// the circuit breaker, unless one is set with WithCircuitBreakerWindow
+const defaultBreakerWindow = time.Minute
+
+// circuitBreaker fails requests fast after too many consecutive failures within
+// a window. Once the cooldown has elapsed, requests are let through again: a
+// success closes the breaker, whereas a failure opens it for another cooldown.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	window    time.Duration
+
+	mu sync.Mutex
+	// failures holds the times of the consecutive failures within the window
+	failures  []time.Time
+	tripped   bool
+	openUntil time.Time
+}
+
+// allow returns ErrCircuitOpen if the breaker is open at now
+func (b *circuitBreaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if until := b.openUntil; now.Before(until) {
+		return fmt.Errorf("%w until %s", ErrCircuitOpen, until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// record counts the outcome of a request, opening the breaker once the
+// threshold of consecutive failures within the window is reached, or on any
+// failure after it has tripped. Transport errors and 5xx responses are
+// failures; cancellations by the caller are ignored.
+func (b *circuitBreaker) record(now time.Time, resp *http.Response, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil && resp.StatusCode < 500 {
+		b.failures = b.failures[:0]
+		b.tripped = false
+		return
+	}
+
+	// Forget failures that are too old to count towards the threshold
+	kept := b.failures[:0]
+	for _, failedAt := range b.failures {
+		if now.Sub(failedAt) < b.window {
+			kept = append(kept, failedAt)
+		}
+	}
+	b.failures = append(kept, now)
+
+	if b.tripped || len(b.failures) >= b.threshold {
+		b.tripped = true
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
@@ -0,0 +1,167 @@
+package skald
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		if healthy.Load() {
+			return mockResponse(200, `{"uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		}
+		return mockResponse(500, `{"error": "internal"}`), nil
+	})
+	clk := newFakeClock()
+	withClock(clk)(client)
+	WithCircuitBreaker(3, time.Minute)(client)
+
+	ctx := context.Background()
+	memoID := "123e4567-e89b-12d3-a456-426614174000"
+
+	// Trip the breaker with consecutive server errors
+	for i := 0; i < 3; i++ {
+		var apiErr *APIError
+		if _, err := client.GetMemo(ctx, memoID); !errors.As(err, &apiErr) {
+			t.Fatalf("expected APIError on request %d, got %v", i, err)
+		}
+	}
+
+	// While open, requests fail fast without being sent
+	if _, err := client.GetMemo(ctx, memoID); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 requests to be sent, got %d", calls.Load())
+	}
+
+	// After the cooldown, a success closes the breaker
+	clk.Advance(time.Minute)
+	healthy.Store(true)
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetMemo(ctx, memoID); err != nil {
+			t.Fatalf("expected recovery after cooldown, got %v", err)
+		}
+	}
+	if calls.Load() != 6 {
+		t.Errorf("expected 6 requests to be sent, got %d", calls.Load())
+	}
+}
+
+func TestCircuitBreakerReopensOnFailureAfterCooldown(t *testing.T) {
+	var calls atomic.Int32
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return mockResponse(503, `{"error": "unavailable"}`), nil
+	})
+	clk := newFakeClock()
+	withClock(clk)(client)
+	WithCircuitBreaker(2, time.Minute)(client)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, _ = client.GetMemo(ctx, "test-uuid")
+	}
+
+	clk.Advance(time.Minute)
+	if _, err := client.GetMemo(ctx, "test-uuid"); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected a trial request after cooldown")
+	}
+	if _, err := client.GetMemo(ctx, "test-uuid"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected breaker to reopen after failed trial request, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 requests to be sent, got %d", calls.Load())
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(404, `{"error": "not found"}`), nil
+	})
+	WithCircuitBreaker(2, time.Minute)(client)
+
+	for i := 0; i < 5; i++ {
+		if _, err := client.GetMemo(context.Background(), "test-uuid"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected 4xx responses not to trip the breaker on request %d", i)
+		}
+	}
+}
+
+func TestCircuitBreakerResetsOnSuccess(t *testing.T) {
+	var calls atomic.Int32
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		// Alternate failures and successes, never reaching the threshold
+		if calls.Add(1)%2 == 1 {
+			return mockResponse(500, `{"error": "internal"}`), nil
+		}
+		return mockResponse(200, `{"uuid": "test-uuid"}`), nil
+	})
+	WithCircuitBreaker(2, time.Minute)(client)
+
+	for i := 0; i < 6; i++ {
+		if _, err := client.GetMemo(context.Background(), "test-uuid"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected breaker to stay closed on request %d", i)
+		}
+	}
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(500, `{"error": "internal"}`), nil
+	})
+	clk := newFakeClock()
+	withClock(clk)(client)
+	WithCircuitBreakerWindow(10 * time.Minute)(client)
+	WithCircuitBreaker(3, time.Minute)(client)
+
+	ctx := context.Background()
+	// Failures hours apart don't trip the breaker
+	for i := 0; i < 5; i++ {
+		if _, err := client.GetMemo(ctx, "test-uuid"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected failures outside the window not to trip the breaker on request %d", i)
+		}
+		clk.Advance(time.Hour)
+	}
+
+	// Failures within the window do
+	for i := 0; i < 3; i++ {
+		clk.Advance(4 * time.Minute)
+		_, _ = client.GetMemo(ctx, "test-uuid")
+	}
+	if _, err := client.GetMemo(ctx, "test-uuid"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected failures within the window to trip the breaker, got %v", err)
+	}
+}
+
+func TestCircuitBreakerDefaultWindow(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(500, `{"error": "internal"}`), nil
+	})
+	clk := newFakeClock()
+	withClock(clk)(client)
+	WithCircuitBreaker(2, time.Minute)(client)
+
+	if client.breaker.window != defaultBreakerWindow {
+		t.Errorf("expected default window %v, got %v", defaultBreakerWindow, client.breaker.window)
+	}
+
+	ctx := context.Background()
+	_, _ = client.GetMemo(ctx, "test-uuid")
+	clk.Advance(defaultBreakerWindow)
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetMemo(ctx, "test-uuid"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected the failure before the window not to count on request %d", i)
+		}
+	}
+	if _, err := client.GetMemo(ctx, "test-uuid"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the breaker to be open after 2 failures within the window, got %v", err)
+	}
+}
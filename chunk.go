@@ -0,0 +1,69 @@
+package skald
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// defaultChunkSize is the chunk length PreviewChunks assumes by default, in
+	// characters
+	defaultChunkSize = 1000
+	// defaultChunkOverlap is the overlap between chunks PreviewChunks assumes by
+	// default, in characters
+	defaultChunkOverlap = 100
+)
+
+// ChunkOptions configures PreviewChunks. The zero value assumes 1000 characters
+// per chunk with an overlap of 100.
+type ChunkOptions struct {
+	// Size is the maximum length of a chunk in characters
+	Size int
+	// Overlap is the number of characters each chunk repeats from the end of
+	// the previous one. It is capped below Size.
+	Overlap int
+}
+
+// PreviewChunks splits content into chunks with a client-side approximation of
+// how memos are chunked when they are indexed, so that input can be tuned before
+// uploading it. The server's chunks may differ. Chunks are at most opts.Size
+// characters long and start opts.Overlap characters before the end of the
+// previous chunk. A chunk that would split a word ends at the last whitespace
+// instead, provided that leaves it longer than the overlap. Chunks are trimmed
+// of surrounding whitespace, and empty chunks are dropped.
+func PreviewChunks(content string, opts ChunkOptions) []string {
+	size, overlap := opts.Size, opts.Overlap
+	if size <= 0 {
+		size, overlap = defaultChunkSize, defaultChunkOverlap
+	}
+	overlap = max(min(overlap, size-1), 0)
+
+	runes := []rune(content)
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) && !unicode.IsSpace(runes[end]) {
+			for i := end - 1; i > start+overlap; i-- {
+				if unicode.IsSpace(runes[i]) {
+					end = i
+					break
+				}
+			}
+		}
+
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+
+	return chunks
+}
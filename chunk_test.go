@@ -0,0 +1,89 @@
+package skald
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPreviewChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		opts     ChunkOptions
+		expected []string
+	}{
+		{
+			name:    "breaks at whitespace with overlap",
+			content: "The quick brown fox jumps over the lazy dog. Pack my box with five dozen liquor jugs.",
+			opts:    ChunkOptions{Size: 30, Overlap: 10},
+			expected: []string{
+				"The quick brown fox jumps over",
+				"jumps over the lazy dog. Pack",
+				"dog. Pack my box with five",
+				"with five dozen liquor jugs.",
+			},
+		},
+		{
+			name:     "no whitespace",
+			content:  "abcdefghijklmnopqrstuvwxyz",
+			opts:     ChunkOptions{Size: 10, Overlap: 3},
+			expected: []string{"abcdefghij", "hijklmnopq", "opqrstuvwx", "vwxyz"},
+		},
+		{
+			name:     "no overlap",
+			content:  "one two three four five six",
+			opts:     ChunkOptions{Size: 10},
+			expected: []string{"one two", "three", "four five", "six"},
+		},
+		{
+			name:     "multibyte characters",
+			content:  "héllo wörld ünïcode",
+			opts:     ChunkOptions{Size: 11},
+			expected: []string{"héllo wörld", "ünïcode"},
+		},
+		{
+			name:     "shorter than size",
+			content:  "  short text  ",
+			opts:     ChunkOptions{Size: 100, Overlap: 10},
+			expected: []string{"short text"},
+		},
+		{
+			name:     "empty",
+			content:  "",
+			opts:     ChunkOptions{Size: 10},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := PreviewChunks(tt.content, tt.opts)
+			if !reflect.DeepEqual(chunks, tt.expected) {
+				t.Errorf("expected chunks %q, got %q", tt.expected, chunks)
+			}
+		})
+	}
+}
+
+func TestPreviewChunksDefaults(t *testing.T) {
+	content := strings.Repeat("a", 2500)
+	chunks := PreviewChunks(content, ChunkOptions{})
+
+	// Chunks start at 0, 900 and 1800 with the default size and overlap
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if len(chunks[0]) != 1000 || len(chunks[1]) != 1000 || len(chunks[2]) != 700 {
+		t.Errorf("expected chunk lengths [1000 1000 700], got [%d %d %d]", len(chunks[0]), len(chunks[1]), len(chunks[2]))
+	}
+}
+
+func TestPreviewChunksOverlapCapped(t *testing.T) {
+	// An overlap at least as large as the size must still make progress
+	chunks := PreviewChunks("abcdef", ChunkOptions{Size: 3, Overlap: 5})
+	expected := []string{"abc", "bcd", "cde", "def"}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected chunks %q, got %q", expected, chunks)
+	}
+}
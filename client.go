@@ -4,26 +4,95 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxFileSize is the maximum size of an uploaded file
+const maxFileSize = 100 * 1024 * 1024 // 100MB
+
+// maxSearchLimit is the maximum number of results a single search can return
+const maxSearchLimit = 50
+
+// syncPageSize is the page size used by SyncMemos and TagMemosMatching
+const syncPageSize = 100
+
+// bulkConcurrency is the maximum number of per-memo requests in flight at once
+// for methods acting on many memos, such as TagMemosMatching and SearchMemos
+const bulkConcurrency = 4
+
+// awaitPollInitialInterval and awaitPollMaxInterval bound the polling interval
+// of AwaitMemoProcessed when no notifier is available
+const (
+	awaitPollInitialInterval = 250 * time.Millisecond
+	awaitPollMaxInterval     = 10 * time.Second
 )
 
+// createLookupTimeout bounds the reference ID lookup of CreateMemo after an
+// ambiguous failure, which runs even when the caller's context is already done
+const createLookupTimeout = 5 * time.Second
+
+// maxDrainSize is the maximum amount of unread response data drained before a
+// body is closed, so that the connection can be reused
+const maxDrainSize = 64 * 1024 // 64KB
+
 // Client is the main Skald SDK client
 type Client struct {
-	apiKey     string
-	baseURL    string
-	httpClient *http.Client
+	apiKey          string
+	baseURL         string
+	httpClient      *http.Client
+	strictDecoding  bool
+	maxResponseSize int64
+	correlationIDs  bool
+	defaultFilters  []Filter
+
+	deduplicateContent bool
+
+	customHTTPClient   bool
+	insecureSkipVerify bool
+
+	fileFieldName      string
+	multipartBoundary  string
+	contentTransformer func(string) string
+
+	apiKeyErr error
+
+	maxRetries        int
+	backoff           BackoffStrategy
+	retryableStatuses map[int]bool
+
+	streamHeartbeat   time.Duration
+	streamPingHandler func(StreamPing)
+
+	defaultContextTimeout time.Duration
+	breaker               *circuitBreaker
+	breakerWindow         time.Duration
+	memoNotifier          MemoNotifier
+	metadataSchema        map[string]reflect.Kind
+	rand                  *lockedRand
+	clock                 clock
 }
 
-// NewClient creates a new Skald client
+// NewClient creates a new Skald client. Leading and trailing whitespace, such as
+// a trailing newline from a secrets file, is trimmed from the API key.
 func NewClient(apiKey string, baseURL ...string) *Client {
 	url := "https://api.useskald.com"
 	if len(baseURL) > 0 && baseURL[0] != "" {
@@ -31,19 +100,53 @@ func NewClient(apiKey string, baseURL ...string) *Client {
 	}
 
 	return &Client{
-		apiKey:     apiKey,
-		baseURL:    url,
-		httpClient: &http.Client{},
+		apiKey:        strings.TrimSpace(apiKey),
+		baseURL:       url,
+		httpClient:    &http.Client{},
+		fileFieldName: "file",
+		backoff:       defaultBackoff,
+		clock:         realClock{},
 	}
 }
 
 // CreateMemo creates a new memo
 func (c *Client) CreateMemo(ctx context.Context, memoData MemoData) (*CreateMemoResponse, error) {
+	if err := c.validateMetadata(memoData.Metadata); err != nil {
+		return nil, err
+	}
+
 	// Initialize metadata to empty map if not provided
 	if memoData.Metadata == nil {
 		memoData.Metadata = make(map[string]interface{})
 	}
 
+	if c.contentTransformer != nil {
+		memoData.Content = c.contentTransformer(memoData.Content)
+	}
+
+	if c.deduplicateContent {
+		hash := HashContent(memoData.Content)
+		existing, err := c.FindMemoByContentHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			memoUUID, err := uuid.Parse(existing.UUID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse memo UUID: %w", err)
+			}
+			return &CreateMemoResponse{MemoUUID: memoUUID}, nil
+		}
+
+		// Copy the metadata so the caller's map isn't modified
+		metadata := make(map[string]interface{}, len(memoData.Metadata)+1)
+		for key, value := range memoData.Metadata {
+			metadata[key] = value
+		}
+		metadata[ContentHashMetadataKey] = hash
+		memoData.Metadata = metadata
+	}
+
 	body, err := json.Marshal(memoData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal memo data: %w", err)
@@ -51,22 +154,111 @@ func (c *Client) CreateMemo(ctx context.Context, memoData MemoData) (*CreateMemo
 
 	resp, err := c.doRequest(ctx, "POST", "/api/v1/memo", nil, bytes.NewReader(body))
 	if err != nil {
+		// The request may have reached the server before failing, so check
+		// whether the memo was created before reporting the error
+		if existing, ok := c.findCreatedMemo(ctx, memoData.ReferenceID); ok {
+			return existing, nil
+		}
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer drainAndClose(resp.Body)
 
 	if err := c.checkResponse(resp); err != nil {
+		if resp.StatusCode == http.StatusConflict {
+			return nil, duplicateReferenceIDError(err, memoData.ReferenceID)
+		}
+		if resp.StatusCode >= 500 {
+			if existing, ok := c.findCreatedMemo(ctx, memoData.ReferenceID); ok {
+				return existing, nil
+			}
+		}
 		return nil, err
 	}
 
 	var result CreateMemoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	return &result, nil
 }
 
+// CreateMemoAndGet creates a new memo and fetches it, returning the full memo
+// instead of only its UUID. The memo is returned as soon as it is created, so
+// it is usually still pending; use WaitForMemoReady and GetMemo to retrieve it
+// once processed.
+func (c *Client) CreateMemoAndGet(ctx context.Context, memoData MemoData) (*Memo, error) {
+	created, err := c.CreateMemo(ctx, memoData)
+	if err != nil {
+		return nil, err
+	}
+
+	memo, err := c.GetMemo(ctx, created.MemoUUID.String())
+	if err != nil {
+		return nil, fmt.Errorf("memo %s was created but could not be retrieved: %w", created.MemoUUID, err)
+	}
+
+	return memo, nil
+}
+
+// findCreatedMemo looks up a memo by reference ID after a create request failed
+// with an ambiguous outcome (timeout or server error), so that retried creates
+// return the existing memo instead of creating a duplicate. The create often
+// failed because ctx expired, so the lookup gets its own short timeout instead.
+func (c *Client) findCreatedMemo(ctx context.Context, referenceID *string) (*CreateMemoResponse, bool) {
+	if referenceID == nil || *referenceID == "" {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), createLookupTimeout)
+	defer cancel()
+
+	memo, err := c.GetMemo(ctx, *referenceID, IDTypeReferenceID)
+	if err != nil {
+		return nil, false
+	}
+
+	memoUUID, err := uuid.Parse(memo.UUID)
+	if err != nil {
+		return nil, false
+	}
+
+	return &CreateMemoResponse{MemoUUID: memoUUID}, true
+}
+
+// ContentHashMetadataKey is the metadata key under which content hashes are
+// stored when content deduplication is enabled
+const ContentHashMetadataKey = "content_hash"
+
+// HashContent returns the hex-encoded SHA-256 hash of a memo's content
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindMemoByContentHash returns the memo whose content hash (stored in its
+// metadata under ContentHashMetadataKey) matches hash, or nil if there is none
+func (c *Client) FindMemoByContentHash(ctx context.Context, hash string) (*MemoListItem, error) {
+	pageSize := 1
+	result, err := c.ListMemos(ctx, &ListMemosParams{
+		PageSize: &pageSize,
+		Filters: []Filter{{
+			Field:      ContentHashMetadataKey,
+			Operator:   FilterOperatorEq,
+			Value:      hash,
+			FilterType: FilterTypeCustomMetadata,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Results) == 0 {
+		return nil, nil
+	}
+	return &result.Results[0], nil
+}
+
 // CreateMemoFromFile creates a new memo by uploading a file
 // Supported file formats: PDF, DOC, DOCX, PPTX
 // Maximum file size: 100MB
@@ -85,23 +277,148 @@ func (c *Client) CreateMemoFromFile(ctx context.Context, filePath string, memoDa
 	}
 
 	// Check file size (100MB limit)
-	const maxFileSize = 100 * 1024 * 1024 // 100MB
 	if fileInfo.Size() > maxFileSize {
 		return nil, fmt.Errorf("file size exceeds 100MB limit")
 	}
 
-	// Create multipart form
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+	return c.CreateMemoFromReader(ctx, file, filepath.Base(filePath), memoData)
+}
+
+// CreateMemoFromReader creates a new memo by uploading the content read from r
+// under the given file name. The upload is streamed with chunked transfer
+// encoding, so r may be of unknown size; uploads exceeding 100MB fail.
+func (c *Client) CreateMemoFromReader(ctx context.Context, r io.Reader, fileName string, memoData *MemoFileData) (*CreateMemoResponse, error) {
+	// Stream the multipart form through a pipe instead of buffering it in memory
+	pr, pw := io.Pipe()
+	defer func() { _ = pr.Close() }()
+
+	writer := multipart.NewWriter(pw)
+	if c.multipartBoundary != "" {
+		if err := writer.SetBoundary(c.multipartBoundary); err != nil {
+			return nil, fmt.Errorf("invalid multipart boundary: %w", err)
+		}
+	}
+	go func() {
+		pw.CloseWithError(writeMultipartMemo(writer, c.fileFieldName, r, fileName, memoData))
+	}()
+
+	// Create request
+	urlStr := c.baseURL + "/api/v1/memo"
+	req, err := http.NewRequestWithContext(ctx, "POST", urlStr, pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	// Execute request
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		if resp.StatusCode == http.StatusConflict && memoData != nil {
+			return nil, duplicateReferenceIDError(err, memoData.ReferenceID)
+		}
+		return nil, err
+	}
+
+	var result CreateMemoResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// CreateMemoFromURL creates a new memo from a document available at a public URL.
+// The URL is passed to the server to fetch; if the server doesn't support fetching
+// URLs, the document is downloaded by the client and uploaded instead, subject to
+// the 100MB upload limit.
+func (c *Client) CreateMemoFromURL(ctx context.Context, fileURL string, memoData *MemoFileData) (*CreateMemoResponse, error) {
+	body, err := json.Marshal(createMemoFromURLRequest{URL: fileURL, MemoFileData: memoData})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal memo data: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/memo/from-url", nil, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		// Fetching URLs is not supported by the server; download and upload the file instead
+		return c.createMemoFromDownload(ctx, fileURL, memoData)
+	}
+
+	if err := c.checkResponse(resp); err != nil {
+		if resp.StatusCode == http.StatusConflict && memoData != nil {
+			return nil, duplicateReferenceIDError(err, memoData.ReferenceID)
+		}
+		return nil, err
+	}
+
+	var result CreateMemoResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// createMemoFromDownload downloads the document at fileURL and uploads it as a memo
+func (c *Client) createMemoFromDownload(ctx context.Context, fileURL string, memoData *MemoFileData) (*CreateMemoResponse, error) {
+	parsedURL, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// The download goes to a third party, so it must not carry the API key
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to download file: unexpected status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxFileSize {
+		return nil, fmt.Errorf("file size exceeds 100MB limit")
+	}
+
+	fileName := path.Base(parsedURL.Path)
+	if fileName == "/" || fileName == "." {
+		fileName = "document"
+	}
+
+	return c.CreateMemoFromReader(ctx, resp.Body, fileName, memoData)
+}
 
+// writeMultipartMemo writes the file content under fieldName and the memo data
+// fields as a multipart form
+func writeMultipartMemo(writer *multipart.Writer, fieldName string, r io.Reader, fileName string, memoData *MemoFileData) error {
 	// Add file field
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+	part, err := writer.CreateFormFile(fieldName, fileName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %w", err)
+		return fmt.Errorf("failed to create form file: %w", err)
 	}
 
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("failed to copy file content: %w", err)
+	// Read one byte past the limit to detect oversized content
+	n, err := io.Copy(part, io.LimitReader(r, maxFileSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to copy file content: %w", err)
+	}
+	if n > maxFileSize {
+		return fmt.Errorf("file size exceeds 100MB limit")
 	}
 
 	// Add memo data fields if provided
@@ -109,21 +426,21 @@ func (c *Client) CreateMemoFromFile(ctx context.Context, filePath string, memoDa
 		// Add title field
 		if memoData.Title != nil {
 			if err := writer.WriteField("title", *memoData.Title); err != nil {
-				return nil, fmt.Errorf("failed to write title field: %w", err)
+				return fmt.Errorf("failed to write title field: %w", err)
 			}
 		}
 
 		// Add source field
 		if memoData.Source != nil {
 			if err := writer.WriteField("source", *memoData.Source); err != nil {
-				return nil, fmt.Errorf("failed to write source field: %w", err)
+				return fmt.Errorf("failed to write source field: %w", err)
 			}
 		}
 
 		// Add reference_id field
 		if memoData.ReferenceID != nil {
 			if err := writer.WriteField("reference_id", *memoData.ReferenceID); err != nil {
-				return nil, fmt.Errorf("failed to write reference_id field: %w", err)
+				return fmt.Errorf("failed to write reference_id field: %w", err)
 			}
 		}
 
@@ -131,67 +448,105 @@ func (c *Client) CreateMemoFromFile(ctx context.Context, filePath string, memoDa
 		if len(memoData.Tags) > 0 {
 			tagsJSON, err := json.Marshal(memoData.Tags)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal tags: %w", err)
+				return fmt.Errorf("failed to marshal tags: %w", err)
 			}
 			if err := writer.WriteField("tags", string(tagsJSON)); err != nil {
-				return nil, fmt.Errorf("failed to write tags field: %w", err)
+				return fmt.Errorf("failed to write tags field: %w", err)
 			}
 		}
 
 		// Add metadata as JSON
 		if len(memoData.Metadata) > 0 {
-			metadataJSON, err := json.Marshal(memoData.Metadata)
+			metadataJSON, err := json.Marshal(utcMetadata(memoData.Metadata))
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+				return fmt.Errorf("failed to marshal metadata: %w", err)
 			}
 			if err := writer.WriteField("metadata", string(metadataJSON)); err != nil {
-				return nil, fmt.Errorf("failed to write metadata field: %w", err)
+				return fmt.Errorf("failed to write metadata field: %w", err)
 			}
 		}
 
 		// Add expiration_date field (RFC3339 format)
 		if memoData.ExpirationDate != nil {
-			if err := writer.WriteField("expiration_date", memoData.ExpirationDate.Format(time.RFC3339)); err != nil {
-				return nil, fmt.Errorf("failed to write expiration_date field: %w", err)
+			if err := writer.WriteField("expiration_date", memoData.ExpirationDate.UTC().Format(time.RFC3339)); err != nil {
+				return fmt.Errorf("failed to write expiration_date field: %w", err)
+			}
+		}
+
+		// Add embedding_model field
+		if memoData.EmbeddingModel != nil {
+			if err := writer.WriteField("embedding_model", *memoData.EmbeddingModel); err != nil {
+				return fmt.Errorf("failed to write embedding_model field: %w", err)
+			}
+		}
+
+		// Add chunk_config as JSON
+		if memoData.ChunkConfig != nil {
+			chunkConfigJSON, err := json.Marshal(memoData.ChunkConfig)
+			if err != nil {
+				return fmt.Errorf("failed to marshal chunk config: %w", err)
+			}
+			if err := writer.WriteField("chunk_config", string(chunkConfigJSON)); err != nil {
+				return fmt.Errorf("failed to write chunk_config field: %w", err)
 			}
 		}
 	}
 
 	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
+		return fmt.Errorf("failed to close multipart writer: %w", err)
 	}
 
-	// Create request
-	urlStr := c.baseURL + "/api/v1/memo"
-	req, err := http.NewRequestWithContext(ctx, "POST", urlStr, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	return nil
+}
+
+// GetMemo retrieves a memo by ID
+func (c *Client) GetMemo(ctx context.Context, memoID string, idType ...IDType) (*Memo, error) {
+	return c.GetMemoWithOptions(ctx, memoID, GetMemoOptions{}, idType...)
+}
+
+// GetMemoWithOptions retrieves a memo by ID, e.g. only some of its fields with
+// opts.Fields to reduce the payload size
+func (c *Client) GetMemoWithOptions(ctx context.Context, memoID string, opts GetMemoOptions, idType ...IDType) (*Memo, error) {
+	idTypeValue := IDTypeMemoUUID
+	if len(idType) > 0 {
+		idTypeValue = idType[0]
+		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+			return nil, fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+		}
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	params := url.Values{}
+	if idTypeValue != IDTypeMemoUUID {
+		params.Set("id_type", string(idTypeValue))
+	}
+	if len(opts.Fields) > 0 {
+		params.Set("fields", strings.Join(opts.Fields, ","))
+	}
 
-	// Execute request
-	resp, err := c.httpClient.Do(req)
+	path := fmt.Sprintf("/api/v1/memo/%s", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "GET", path, params, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer drainAndClose(resp.Body)
 
 	if err := c.checkResponse(resp); err != nil {
 		return nil, err
 	}
 
-	var result CreateMemoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	var memo Memo
+	if err := c.decodeJSON(resp.Body, &memo); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	memo.ETag = resp.Header.Get("ETag")
 
-	return &result, nil
+	return &memo, nil
 }
 
-// GetMemo retrieves a memo by ID
-func (c *Client) GetMemo(ctx context.Context, memoID string, idType ...IDType) (*Memo, error) {
+// GetMemoRaw is like GetMemo, but returns the undecoded JSON response body so it
+// can be decoded into a caller-defined struct, e.g. to access fields the SDK
+// doesn't model yet
+func (c *Client) GetMemoRaw(ctx context.Context, memoID string, idType ...IDType) (json.RawMessage, error) {
 	idTypeValue := IDTypeMemoUUID
 	if len(idType) > 0 {
 		idTypeValue = idType[0]
@@ -210,57 +565,74 @@ func (c *Client) GetMemo(ctx context.Context, memoID string, idType ...IDType) (
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer drainAndClose(resp.Body)
 
 	if err := c.checkResponse(resp); err != nil {
 		return nil, err
 	}
 
-	var memo Memo
-	if err := json.NewDecoder(resp.Body).Decode(&memo); err != nil {
+	var raw json.RawMessage
+	if err := c.decodeJSON(resp.Body, &raw); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &memo, nil
+	return raw, nil
 }
 
-// ListMemos retrieves a paginated list of memos
-func (c *Client) ListMemos(ctx context.Context, params *ListMemosParams) (*ListMemosResponse, error) {
-	queryParams := url.Values{}
-	if params != nil {
-		if params.Page != nil {
-			queryParams.Set("page", fmt.Sprintf("%d", *params.Page))
-		}
-		if params.PageSize != nil {
-			queryParams.Set("page_size", fmt.Sprintf("%d", *params.PageSize))
+// RegenerateSummary asks the server to regenerate the AI summary of a memo and returns
+// the new summary. If the server accepts the request for asynchronous processing
+// (202 Accepted), an empty summary is returned; use CheckMemoStatus or
+// WaitForMemoReady and then GetMemoSummary to retrieve it.
+func (c *Client) RegenerateSummary(ctx context.Context, memoID string, idType ...IDType) (string, error) {
+	idTypeValue := IDTypeMemoUUID
+	if len(idType) > 0 {
+		idTypeValue = idType[0]
+		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+			return "", fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
 		}
 	}
 
-	resp, err := c.doRequest(ctx, "GET", "/api/v1/memo", queryParams, nil)
+	params := url.Values{}
+	if idTypeValue != IDTypeMemoUUID {
+		params.Set("id_type", string(idTypeValue))
+	}
+
+	path := fmt.Sprintf("/api/v1/memo/%s/summary/regenerate", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "POST", path, params, nil)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer drainAndClose(resp.Body)
 
 	if err := c.checkResponse(resp); err != nil {
-		return nil, err
+		return "", err
 	}
 
-	var result ListMemosResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if resp.StatusCode == http.StatusAccepted {
+		return "", nil
 	}
 
-	return &result, nil
+	var result memoSummaryResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Summary, nil
 }
 
-// UpdateMemo updates an existing memo
-func (c *Client) UpdateMemo(ctx context.Context, memoID string, updateData UpdateMemoData, idType ...IDType) (*UpdateMemoResponse, error) {
+// GetMemoContent retrieves a slice of a memo's content starting at offset (in characters)
+// and at most length characters long. The range is requested from the server; if the
+// server does not support content ranges, the full memo is fetched and sliced client-side.
+func (c *Client) GetMemoContent(ctx context.Context, memoID string, offset, length int, idType ...IDType) (string, error) {
+	if offset < 0 || length < 0 {
+		return "", fmt.Errorf("offset and length must be non-negative")
+	}
+
 	idTypeValue := IDTypeMemoUUID
 	if len(idType) > 0 {
 		idTypeValue = idType[0]
 		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
-			return nil, fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+			return "", fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
 		}
 	}
 
@@ -268,38 +640,82 @@ func (c *Client) UpdateMemo(ctx context.Context, memoID string, updateData Updat
 	if idTypeValue != IDTypeMemoUUID {
 		params.Set("id_type", string(idTypeValue))
 	}
+	params.Set("offset", fmt.Sprintf("%d", offset))
+	params.Set("length", fmt.Sprintf("%d", length))
 
-	body, err := json.Marshal(updateData)
+	path := fmt.Sprintf("/api/v1/memo/%s/content", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "GET", path, params, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal update data: %w", err)
+		return "", err
 	}
+	defer drainAndClose(resp.Body)
 
-	path := fmt.Sprintf("/api/v1/memo/%s", url.PathEscape(memoID))
-	resp, err := c.doRequest(ctx, "PATCH", path, params, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		// Content ranges are not supported by the server; fall back to slicing the full content
+		memo, err := c.GetMemo(ctx, memoID, idTypeValue)
+		if err != nil {
+			return "", err
+		}
+		return sliceContent(memo.Content, offset, length), nil
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	if err := c.checkResponse(resp); err != nil {
+		return "", err
+	}
+
+	var result memoContentResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Content, nil
+}
+
+// sliceContent returns at most length characters of content starting at offset
+func sliceContent(content string, offset, length int) string {
+	runes := []rune(content)
+	if offset >= len(runes) {
+		return ""
+	}
+	end := offset + length
+	if end > len(runes) {
+		end = len(runes)
+	}
+	return string(runes[offset:end])
+}
+
+// ListMemoChunks retrieves one page of a memo's content chunks. Unlike GetMemo,
+// which returns all chunks inline, this allows paging lazily through the chunks
+// of large documents. Pages are numbered from 1.
+func (c *Client) ListMemoChunks(ctx context.Context, memoID string, page, pageSize int, idType ...IDType) ([]MemoChunk, error) {
+	result, err := c.listMemoChunks(ctx, memoID, page, pageSize, idType...)
+	if err != nil {
 		return nil, err
 	}
 
-	var result UpdateMemoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	return result.Results, nil
+}
+
+// GetMemoChunkCount returns the number of chunks a memo was split into, e.g. to
+// gauge the size of an uploaded document, without loading the chunks
+func (c *Client) GetMemoChunkCount(ctx context.Context, memoID string, idType ...IDType) (int, error) {
+	// The count is reported with every page, so request the smallest one
+	result, err := c.listMemoChunks(ctx, memoID, 1, 1, idType...)
+	if err != nil {
+		return 0, err
 	}
 
-	return &result, nil
+	return result.Count, nil
 }
 
-// DeleteMemo deletes a memo
-func (c *Client) DeleteMemo(ctx context.Context, memoID string, idType ...IDType) error {
+// listMemoChunks retrieves one page of a memo's content chunks along with the
+// total number of chunks
+func (c *Client) listMemoChunks(ctx context.Context, memoID string, page, pageSize int, idType ...IDType) (*listMemoChunksResponse, error) {
 	idTypeValue := IDTypeMemoUUID
 	if len(idType) > 0 {
 		idTypeValue = idType[0]
 		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
-			return fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+			return nil, fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
 		}
 	}
 
@@ -307,29 +723,36 @@ func (c *Client) DeleteMemo(ctx context.Context, memoID string, idType ...IDType
 	if idTypeValue != IDTypeMemoUUID {
 		params.Set("id_type", string(idTypeValue))
 	}
+	params.Set("page", fmt.Sprintf("%d", page))
+	params.Set("page_size", fmt.Sprintf("%d", pageSize))
 
-	path := fmt.Sprintf("/api/v1/memo/%s", url.PathEscape(memoID))
-	resp, err := c.doRequest(ctx, "DELETE", path, params, nil)
+	path := fmt.Sprintf("/api/v1/memo/%s/chunks", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "GET", path, params, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer drainAndClose(resp.Body)
 
 	if err := c.checkResponse(resp); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	var result listMemoChunksResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
 }
 
-// CheckMemoStatus checks the processing status of a memo
-// The memo can be identified by UUID (default) or reference ID
-func (c *Client) CheckMemoStatus(ctx context.Context, memoID string, idType ...IDType) (*MemoStatusResponse, error) {
+// GetMemoSummary retrieves only the AI-generated summary of a memo,
+// without transferring its full content
+func (c *Client) GetMemoSummary(ctx context.Context, memoID string, idType ...IDType) (string, error) {
 	idTypeValue := IDTypeMemoUUID
 	if len(idType) > 0 {
 		idTypeValue = idType[0]
 		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
-			return nil, fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+			return "", fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
 		}
 	}
 
@@ -338,62 +761,1350 @@ func (c *Client) CheckMemoStatus(ctx context.Context, memoID string, idType ...I
 		params.Set("id_type", string(idTypeValue))
 	}
 
-	path := fmt.Sprintf("/api/v1/memo/%s/status", url.PathEscape(memoID))
+	path := fmt.Sprintf("/api/v1/memo/%s/summary", url.PathEscape(memoID))
 	resp, err := c.doRequest(ctx, "GET", path, params, nil)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer drainAndClose(resp.Body)
 
 	if err := c.checkResponse(resp); err != nil {
-		return nil, err
+		return "", err
 	}
 
-	var status MemoStatusResponse
-	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	var result memoSummaryResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &status, nil
+	return result.Summary, nil
 }
 
-// WaitForMemoReady polls the memo status until it's processed or an error occurs.
+// GetMemoTags retrieves only the tags of a memo, which is lighter than GetMemo
+func (c *Client) GetMemoTags(ctx context.Context, memoID string, idType ...IDType) ([]MemoTag, error) {
+	idTypeValue := IDTypeMemoUUID
+	if len(idType) > 0 {
+		idTypeValue = idType[0]
+		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+			return nil, fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+		}
+	}
+
+	params := url.Values{}
+	if idTypeValue != IDTypeMemoUUID {
+		params.Set("id_type", string(idTypeValue))
+	}
+
+	path := fmt.Sprintf("/api/v1/memo/%s/tags", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "GET", path, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var tags []MemoTag
+	if err := c.decodeJSON(resp.Body, &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return tags, nil
+}
+
+// AddMemoTags adds tags to a memo, keeping its existing tags
+func (c *Client) AddMemoTags(ctx context.Context, memoID string, tags []string, idType ...IDType) error {
+	idTypeValue := IDTypeMemoUUID
+	if len(idType) > 0 {
+		idTypeValue = idType[0]
+		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+			return fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+		}
+	}
+
+	params := url.Values{}
+	if idTypeValue != IDTypeMemoUUID {
+		params.Set("id_type", string(idTypeValue))
+	}
+
+	body, err := json.Marshal(addMemoTagsRequest{Tags: tags})
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	path := fmt.Sprintf("/api/v1/memo/%s/tags", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "POST", path, params, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TagMemosMatching adds tags to every memo matching filters, e.g. to reorganize
+// a corpus. All pages of matches are listed before any memo is tagged, so that
+// tagging can't shift the pages, and then up to bulkConcurrency memos are tagged
+// at once. It returns the number of memos tagged; if some memos could not be
+// tagged, the error is a *TagMemosError listing them.
+func (c *Client) TagMemosMatching(ctx context.Context, filters []Filter, tags []string) (int, error) {
+	if len(tags) == 0 {
+		return 0, fmt.Errorf("at least one tag is required")
+	}
+
+	var memoUUIDs []string
+	page := 1
+	pageSize := syncPageSize
+	for {
+		resp, err := c.ListMemos(ctx, &ListMemosParams{Page: &page, PageSize: &pageSize, Filters: filters})
+		if err != nil {
+			return 0, err
+		}
+		for _, item := range resp.Results {
+			memoUUIDs = append(memoUUIDs, item.UUID)
+		}
+
+		if resp.Next == nil || len(resp.Results) == 0 {
+			break
+		}
+		next, ok := ParsePageFromURL(*resp.Next)
+		if !ok || next <= page {
+			next = page + 1
+		}
+		page = next
+	}
+
+	errs := make([]error, len(memoUUIDs))
+	forEachConcurrently(len(memoUUIDs), bulkConcurrency, func(i int) {
+		errs[i] = c.AddMemoTags(ctx, memoUUIDs[i], tags)
+	})
+
+	tagged := 0
+	failed := make(map[string]error)
+	for i, err := range errs {
+		if err != nil {
+			failed[memoUUIDs[i]] = err
+			continue
+		}
+		tagged++
+	}
+	if len(failed) > 0 {
+		return tagged, &TagMemosError{Failed: failed}
+	}
+
+	return tagged, nil
+}
+
+// ResolveMemoUUID looks up a memo by its client reference ID and returns its UUID
+func (c *Client) ResolveMemoUUID(ctx context.Context, referenceID string) (string, error) {
+	memo, err := c.GetMemo(ctx, referenceID, IDTypeReferenceID)
+	if err != nil {
+		return "", err
+	}
+
+	return memo.UUID, nil
+}
+
+// DescribeMetadataFields returns the custom metadata keys present across all memos.
+// Use it with UnknownMetadataKeys to catch filters on keys that don't exist,
+// which otherwise silently match nothing.
+func (c *Client) DescribeMetadataFields(ctx context.Context) ([]MetadataField, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/memo/metadata-fields", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result metadataFieldsResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Fields, nil
+}
+
+// GetUsage retrieves the account's plan, usage and limits, e.g. to show users
+// how close they are to their quotas
+func (c *Client) GetUsage(ctx context.Context) (*UsageInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/usage", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var usage UsageInfo
+	if err := c.decodeJSON(resp.Body, &usage); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &usage, nil
+}
+
+// ServerInfo returns the version of the Skald server and the features it
+// supports, e.g. for diagnostics or to adapt to older servers
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/version", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var info ServerInfo
+	if err := c.decodeJSON(resp.Body, &info); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &info, nil
+}
+
+// RegisterWebhook subscribes webhookURL to the given events (e.g.
+// WebhookEventMemoProcessed), as an alternative to polling for memo status
+func (c *Client) RegisterWebhook(ctx context.Context, webhookURL string, events []string) (*Webhook, error) {
+	if webhookURL == "" {
+		return nil, fmt.Errorf("webhook URL is required")
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("at least one event is required")
+	}
+
+	body, err := json.Marshal(registerWebhookRequest{URL: webhookURL, Events: events})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/webhooks", nil, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var webhook Webhook
+	if err := c.decodeJSON(resp.Body, &webhook); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+// ListWebhooks retrieves the registered webhooks
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/webhooks", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var webhooks []Webhook
+	if err := c.decodeJSON(resp.Body, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook unsubscribes a webhook by UUID
+func (c *Client) DeleteWebhook(ctx context.Context, webhookUUID string) error {
+	path := fmt.Sprintf("/api/v1/webhooks/%s", url.PathEscape(webhookUUID))
+	resp, err := c.doRequest(ctx, "DELETE", path, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	return c.checkResponse(resp)
+}
+
+// ListMemos retrieves a paginated list of memos
+func (c *Client) ListMemos(ctx context.Context, params *ListMemosParams) (*ListMemosResponse, error) {
+	queryParams, err := listMemosQuery(params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/memo", queryParams, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result ListMemosResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// CountMatchingMemos returns the number of memos matching filters, e.g. to check
+// a filter before running an expensive search or chat with it
+func (c *Client) CountMatchingMemos(ctx context.Context, filters []Filter) (int, error) {
+	// The count is reported with every page, so request the smallest one
+	page := 1
+	pageSize := 1
+	resp, err := c.ListMemos(ctx, &ListMemosParams{Page: &page, PageSize: &pageSize, Filters: filters})
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.Count, nil
+}
+
+// StreamListMemos is like ListMemos, but calls handler for each memo of the page
+// as it is decoded instead of decoding the whole response into memory, for
+// pages too large to hold at once. If handler returns an error, StreamListMemos
+// stops and returns it.
+func (c *Client) StreamListMemos(ctx context.Context, params *ListMemosParams, handler func(MemoListItem) error) error {
+	queryParams, err := listMemosQuery(params)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/memo", queryParams, nil)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return err
+	}
+
+	return c.decodeListMemosStream(resp.Body, handler)
+}
+
+// decodeListMemosStream walks the tokens of a list memos response, decoding the
+// items of "results" one at a time and skipping the other fields
+func (c *Client) decodeListMemosStream(body io.Reader, handler func(MemoListItem) error) error {
+	decoder := c.newDecoder(body)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		key, _ := token.(string)
+
+		switch key {
+		case "results":
+			token, err := decoder.Token()
+			if err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+			if token == nil {
+				continue
+			}
+			if token != json.Delim('[') {
+				return fmt.Errorf("failed to decode response: expected results array, got %v", token)
+			}
+
+			for decoder.More() {
+				var item MemoListItem
+				if err := decoder.Decode(&item); err != nil {
+					return fmt.Errorf("failed to decode response: %w", err)
+				}
+				if err := handler(item); err != nil {
+					return err
+				}
+			}
+			if err := expectDelim(decoder, ']'); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+		default:
+			if c.strictDecoding && key != "count" && key != "next" && key != "previous" {
+				return fmt.Errorf("failed to decode response: unknown field %q", key)
+			}
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+	}
+
+	if err := expectDelim(decoder, '}'); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// expectDelim reads the next token and returns an error unless it is delim
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// ListMemosRaw is like ListMemos, but returns the undecoded JSON response body
+func (c *Client) ListMemosRaw(ctx context.Context, params *ListMemosParams) (json.RawMessage, error) {
+	queryParams, err := listMemosQuery(params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/memo", queryParams, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if err := c.decodeJSON(resp.Body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return raw, nil
+}
+
+// listMemosQuery builds the query parameters for listing memos
+func listMemosQuery(params *ListMemosParams) (url.Values, error) {
+	queryParams := url.Values{}
+	if params == nil {
+		return queryParams, nil
+	}
+
+	if params.Page != nil {
+		queryParams.Set("page", fmt.Sprintf("%d", *params.Page))
+	}
+	if params.PageSize != nil {
+		queryParams.Set("page_size", fmt.Sprintf("%d", *params.PageSize))
+	}
+	if len(params.Filters) > 0 {
+		filtersJSON, err := json.Marshal(params.Filters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal filters: %w", err)
+		}
+		queryParams.Set("filters", string(filtersJSON))
+	}
+	if params.Status != nil {
+		queryParams.Set("status", string(*params.Status))
+	}
+	if len(params.Fields) > 0 {
+		queryParams.Set("fields", strings.Join(params.Fields, ","))
+	}
+
+	return queryParams, nil
+}
+
+// SyncMemos pages through the memos updated at or after since and calls handler
+// for each, for incremental syncs. The memos are listed with an updated_at
+// filter, so each run only fetches what changed. It returns the latest UpdatedAt
+// seen, to be passed as since on the next sync; memos updated exactly at that
+// time are handled again, so handlers should be idempotent. If no memo was handled, since
+// itself is returned. If handler or a list request fails, SyncMemos stops and
+// returns since along with the error, so the sync can be retried from the same point.
+func (c *Client) SyncMemos(ctx context.Context, since time.Time, handler func(MemoListItem) error) (time.Time, error) {
+	var filters []Filter
+	if !since.IsZero() {
+		filters = []Filter{{
+			Field:      "updated_at",
+			Operator:   FilterOperatorGte,
+			Value:      since,
+			FilterType: FilterTypeNativeField,
+		}}
+	}
+
+	watermark := since
+	page := 1
+	pageSize := syncPageSize
+	for {
+		resp, err := c.ListMemos(ctx, &ListMemosParams{Page: &page, PageSize: &pageSize, Filters: filters})
+		if err != nil {
+			return since, err
+		}
+
+		for _, item := range resp.Results {
+			// Also checked here in case the server doesn't apply the filter
+			if item.UpdatedAt.Before(since) {
+				continue
+			}
+			if err := handler(item); err != nil {
+				return since, err
+			}
+			if item.UpdatedAt.After(watermark) {
+				watermark = item.UpdatedAt
+			}
+		}
+
+		if resp.Next == nil || len(resp.Results) == 0 {
+			return watermark, nil
+		}
+		next, ok := ParsePageFromURL(*resp.Next)
+		if !ok || next <= page {
+			next = page + 1
+		}
+		page = next
+	}
+}
+
+// ParsePageFromURL extracts the page number from a pagination URL such as
+// ListMemosResponse.Next. It returns false if the URL is malformed or has no
+// valid page query parameter.
+func ParsePageFromURL(raw string) (page int, ok bool) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return 0, false
+	}
+
+	page, err = strconv.Atoi(parsed.Query().Get("page"))
+	if err != nil || page < 1 {
+		return 0, false
+	}
+
+	return page, true
+}
+
+// ListMemosWithAllTags lists memos that have every one of the given tags (AND semantics).
+// Each tag becomes its own filter, and the API combines multiple filters with AND.
+func (c *Client) ListMemosWithAllTags(ctx context.Context, tags []string, params *ListMemosParams) (*ListMemosResponse, error) {
+	return c.ListMemos(ctx, withFilters(params, allTagsFilters(tags)))
+}
+
+// ListMemosWithAnyTags lists memos that have at least one of the given tags (OR semantics).
+// The tags are sent as a single "in" filter.
+func (c *Client) ListMemosWithAnyTags(ctx context.Context, tags []string, params *ListMemosParams) (*ListMemosResponse, error) {
+	return c.ListMemos(ctx, withFilters(params, anyTagsFilters(tags)))
+}
+
+// allTagsFilters builds one "in" filter per tag so that all tags must match
+func allTagsFilters(tags []string) []Filter {
+	filters := make([]Filter, 0, len(tags))
+	for _, tag := range tags {
+		filters = append(filters, Filter{
+			Field:      "tags",
+			Operator:   FilterOperatorIn,
+			Value:      []string{tag},
+			FilterType: FilterTypeNativeField,
+		})
+	}
+	return filters
+}
+
+// anyTagsFilters builds a single "in" filter matching any of the tags
+func anyTagsFilters(tags []string) []Filter {
+	if len(tags) == 0 {
+		return nil
+	}
+	return []Filter{{
+		Field:      "tags",
+		Operator:   FilterOperatorIn,
+		Value:      tags,
+		FilterType: FilterTypeNativeField,
+	}}
+}
+
+// withFilters returns a copy of params with the given filters appended
+func withFilters(params *ListMemosParams, filters []Filter) *ListMemosParams {
+	merged := ListMemosParams{}
+	if params != nil {
+		merged = *params
+	}
+	merged.Filters = append(append([]Filter{}, merged.Filters...), filters...)
+	return &merged
+}
+
+// UpdateMemo updates an existing memo
+func (c *Client) UpdateMemo(ctx context.Context, memoID string, updateData UpdateMemoData, idType ...IDType) (*UpdateMemoResponse, error) {
+	idTypeValue := IDTypeMemoUUID
+	if len(idType) > 0 {
+		idTypeValue = idType[0]
+		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+			return nil, fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+		}
+	}
+
+	params := url.Values{}
+	if idTypeValue != IDTypeMemoUUID {
+		params.Set("id_type", string(idTypeValue))
+	}
+
+	if err := c.validateMetadata(updateData.Metadata); err != nil {
+		return nil, err
+	}
+
+	if c.contentTransformer != nil && updateData.Content != nil {
+		content := c.contentTransformer(*updateData.Content)
+		updateData.Content = &content
+	}
+
+	body, err := json.Marshal(updateData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal update data: %w", err)
+	}
+
+	var headers http.Header
+	if updateData.IfMatch != "" {
+		headers = http.Header{}
+		headers.Set("If-Match", updateData.IfMatch)
+	}
+
+	path := fmt.Sprintf("/api/v1/memo/%s", url.PathEscape(memoID))
+	resp, err := c.doRequestWithHeaders(ctx, "PATCH", path, params, bytes.NewReader(body), headers)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result UpdateMemoResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// AppendMemoContent appends text to the content of a memo on the server, so
+// that log-style entries can be added without sending the whole content. If
+// the server doesn't support appending, the memo is read and updated with its
+// ETag as If-Match instead; the update then fails with a 409 Conflict error if
+// the memo was modified in the meantime.
+func (c *Client) AppendMemoContent(ctx context.Context, memoID string, text string, idType ...IDType) (*UpdateMemoResponse, error) {
+	idTypeValue := IDTypeMemoUUID
+	if len(idType) > 0 {
+		idTypeValue = idType[0]
+		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+			return nil, fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+		}
+	}
+
+	params := url.Values{}
+	if idTypeValue != IDTypeMemoUUID {
+		params.Set("id_type", string(idTypeValue))
+	}
+
+	body, err := json.Marshal(appendMemoContentRequest{Content: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal append request: %w", err)
+	}
+
+	path := fmt.Sprintf("/api/v1/memo/%s/append", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "POST", path, params, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		// Appending is not supported by the server; fall back to a versioned update
+		return c.appendMemoContentWithUpdate(ctx, memoID, text, idTypeValue)
+	}
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result UpdateMemoResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// appendMemoContentWithUpdate appends text to a memo by reading it and updating
+// its content only if it hasn't changed since
+func (c *Client) appendMemoContentWithUpdate(ctx context.Context, memoID string, text string, idType IDType) (*UpdateMemoResponse, error) {
+	memo, err := c.GetMemo(ctx, memoID, idType)
+	if err != nil {
+		return nil, err
+	}
+	if memo.ETag == "" {
+		return nil, fmt.Errorf("cannot append to memo %s: server supports neither appending nor versioned updates", memoID)
+	}
+
+	content := memo.Content + text
+	return c.UpdateMemo(ctx, memoID, UpdateMemoData{
+		Content: &content,
+		IfMatch: memo.ETag,
+	}, idType)
+}
+
+// DeleteMemo deletes a memo
+func (c *Client) DeleteMemo(ctx context.Context, memoID string, idType ...IDType) error {
+	return c.DeleteMemoWithOptions(ctx, memoID, DeleteMemoOptions{}, idType...)
+}
+
+// DeleteMemoWithOptions deletes a memo, e.g. bypassing soft delete with opts.Hard
+func (c *Client) DeleteMemoWithOptions(ctx context.Context, memoID string, opts DeleteMemoOptions, idType ...IDType) error {
+	idTypeValue := IDTypeMemoUUID
+	if len(idType) > 0 {
+		idTypeValue = idType[0]
+		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+			return fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+		}
+	}
+
+	params := url.Values{}
+	if idTypeValue != IDTypeMemoUUID {
+		params.Set("id_type", string(idTypeValue))
+	}
+	if opts.Hard {
+		params.Set("hard", "true")
+	}
+
+	path := fmt.Sprintf("/api/v1/memo/%s", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "DELETE", path, params, nil)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CancelMemoProcessing aborts the processing of a memo that is still being processed.
+// If the memo has already finished processing, the returned error wraps
+// ErrMemoAlreadyProcessed; delete the memo instead if it is no longer wanted.
+func (c *Client) CancelMemoProcessing(ctx context.Context, memoID string, idType ...IDType) error {
+	idTypeValue := IDTypeMemoUUID
+	if len(idType) > 0 {
+		idTypeValue = idType[0]
+		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+			return fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+		}
+	}
+
+	params := url.Values{}
+	if idTypeValue != IDTypeMemoUUID {
+		params.Set("id_type", string(idTypeValue))
+	}
+
+	path := fmt.Sprintf("/api/v1/memo/%s/cancel", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "POST", path, params, nil)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		if resp.StatusCode == http.StatusConflict {
+			return fmt.Errorf("%w: %w", ErrMemoAlreadyProcessed, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Batch executes several create, update and delete operations in a single round trip.
+// Results are returned in the same order as the operations, even if the server
+// reports them out of order; a failed operation
+// has a non-nil BatchResult.Err and does not stop the others. If the server does
+// not support batch requests, the operations are executed sequentially instead.
+// The returned error is only non-nil if the batch as a whole could not be executed,
+// e.g. because an operation's metadata doesn't match the schema set with
+// WithMetadataSchema, in which case no operation is sent.
+func (c *Client) Batch(ctx context.Context, ops []BatchOp) ([]BatchResult, error) {
+	if err := c.validateBatchMetadata(ops); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(batchRequest{Operations: c.prepareBatchOps(ops)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/memo/batch", nil, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		// Batch requests are not supported by the server; fall back to one request per operation
+		return c.batchSequential(ctx, ops), nil
+	}
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result batchResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Results) != len(ops) {
+		return nil, fmt.Errorf("expected %d batch results, got %d", len(ops), len(result.Results))
+	}
+
+	// Place results in index-keyed slots, as the server may complete
+	// operations out of order
+	results := make([]BatchResult, len(ops))
+	filled := make([]bool, len(ops))
+	for i, opResult := range result.Results {
+		slot := i
+		if opResult.Index != nil {
+			slot = *opResult.Index
+		}
+		if slot < 0 || slot >= len(ops) || filled[slot] {
+			return nil, fmt.Errorf("invalid batch result index %d", slot)
+		}
+		filled[slot] = true
+
+		results[slot].MemoUUID = opResult.MemoUUID
+		if opResult.Error != "" {
+			results[slot].Err = &APIError{
+				StatusCode: opResult.StatusCode,
+				Message:    opResult.Error,
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// batchSequential executes batch operations one at a time
+// validateBatchMetadata checks the metadata of creates and updates as CreateMemo
+// and UpdateMemo do, so that the schema is also enforced by the batch endpoint
+func (c *Client) validateBatchMetadata(ops []BatchOp) error {
+	for i, op := range ops {
+		var metadata map[string]interface{}
+		switch {
+		case op.Memo != nil:
+			metadata = op.Memo.Metadata
+		case op.Update != nil:
+			metadata = op.Update.Metadata
+		}
+		if err := c.validateMetadata(metadata); err != nil {
+			return fmt.Errorf("batch operation %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// prepareBatchOps returns a copy of ops with the content of creates and updates
+// transformed as CreateMemo and UpdateMemo do, for sending them to the batch
+// endpoint. The caller's ops are not modified.
+func (c *Client) prepareBatchOps(ops []BatchOp) []BatchOp {
+	if c.contentTransformer == nil {
+		return ops
+	}
+
+	prepared := make([]BatchOp, len(ops))
+	for i, op := range ops {
+		if op.Memo != nil {
+			memo := *op.Memo
+			memo.Content = c.contentTransformer(memo.Content)
+			op.Memo = &memo
+		}
+		if op.Update != nil && op.Update.Content != nil {
+			update := *op.Update
+			content := c.contentTransformer(*update.Content)
+			update.Content = &content
+			op.Update = &update
+		}
+		prepared[i] = op
+	}
+	return prepared
+}
+
+func (c *Client) batchSequential(ctx context.Context, ops []BatchOp) []BatchResult {
+	results := make([]BatchResult, len(ops))
+	for i, op := range ops {
+		var idType []IDType
+		if op.IDType != "" {
+			idType = []IDType{op.IDType}
+		}
+
+		switch op.Operation {
+		case BatchOperationCreate:
+			if op.Memo == nil {
+				results[i].Err = fmt.Errorf("batch operation %d: create requires Memo", i)
+				continue
+			}
+			resp, err := c.CreateMemo(ctx, *op.Memo)
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			results[i].MemoUUID = resp.MemoUUID.String()
+		case BatchOperationUpdate:
+			if op.Update == nil {
+				results[i].Err = fmt.Errorf("batch operation %d: update requires Update", i)
+				continue
+			}
+			resp, err := c.UpdateMemo(ctx, op.MemoID, *op.Update, idType...)
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			results[i].MemoUUID = resp.MemoUUID.String()
+		case BatchOperationDelete:
+			results[i].Err = c.DeleteMemo(ctx, op.MemoID, idType...)
+		default:
+			results[i].Err = fmt.Errorf("batch operation %d: unknown operation %q", i, op.Operation)
+		}
+	}
+	return results
+}
+
+// CheckMemoStatus checks the processing status of a memo
+// The memo can be identified by UUID (default) or reference ID
+func (c *Client) CheckMemoStatus(ctx context.Context, memoID string, idType ...IDType) (*MemoStatusResponse, error) {
+	idTypeValue := IDTypeMemoUUID
+	if len(idType) > 0 {
+		idTypeValue = idType[0]
+		if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+			return nil, fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+		}
+	}
+
+	params := url.Values{}
+	if idTypeValue != IDTypeMemoUUID {
+		params.Set("id_type", string(idTypeValue))
+	}
+
+	path := fmt.Sprintf("/api/v1/memo/%s/status", url.PathEscape(memoID))
+	resp, err := c.doRequest(ctx, "GET", path, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var status MemoStatusResponse
+	if err := c.decodeJSON(resp.Body, &status); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &status, nil
+}
+
+// WaitForMemoReady polls the memo status until it's processed or an error occurs.
 // It returns when the memo is processed, or an error if processing fails or context is cancelled.
 // The pollInterval specifies how long to wait between status checks.
 func (c *Client) WaitForMemoReady(ctx context.Context, memoID string, pollInterval time.Duration, idType ...IDType) error {
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
+	return c.waitForMemoReady(ctx, memoID, func(int) time.Duration { return pollInterval }, idType...)
+}
+
+// WaitForMemoReadyWithBackoff is like WaitForMemoReady, but doubles the wait between
+// status checks after each poll, starting at initialInterval and capped at maxInterval.
+// Short jobs are detected quickly while long jobs don't poll the API excessively.
+func (c *Client) WaitForMemoReadyWithBackoff(ctx context.Context, memoID string, initialInterval, maxInterval time.Duration, idType ...IDType) error {
+	return c.waitForMemoReady(ctx, memoID, func(attempt int) time.Duration {
+		return backoffInterval(initialInterval, maxInterval, attempt)
+	}, idType...)
+}
+
+// backoffInterval returns initial doubled attempt times, capped at maxInterval
+func backoffInterval(initial, maxInterval time.Duration, attempt int) time.Duration {
+	interval := initial
+	for i := 0; i < attempt && interval < maxInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
+
+// waitForMemoReady polls the memo status, waiting interval(attempt) between polls
+func (c *Client) waitForMemoReady(ctx context.Context, memoID string, interval func(attempt int) time.Duration, idType ...IDType) error {
+	for attempt := 0; ; attempt++ {
+		status, err := c.CheckMemoStatus(ctx, memoID, idType...)
+		if err != nil {
+			return err
+		}
+
+		switch status.Readiness() {
+		case MemoReadinessReady:
+			return nil
+		case MemoReadinessError:
+			return memoProcessingError(status)
+		case MemoReadinessQueued, MemoReadinessProcessing:
+			// Continue polling
+		}
+
+		if err := c.sleep(ctx, interval(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// StreamMemoStatus streams a memo's status updates as the server pushes them
+// during processing, instead of polling CheckMemoStatus. The stream ends after
+// the memo has been processed or has failed (status "processed" or "error").
+// The memo can be identified by UUID (default) or reference ID.
+func (c *Client) StreamMemoStatus(ctx context.Context, memoID string, idType ...IDType) (<-chan MemoStatusResponse, <-chan error) {
+	statusChan := make(chan MemoStatusResponse)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(statusChan)
+		defer close(errChan)
+
+		idTypeValue := IDTypeMemoUUID
+		if len(idType) > 0 {
+			idTypeValue = idType[0]
+			if idTypeValue != IDTypeMemoUUID && idTypeValue != IDTypeReferenceID {
+				errChan <- fmt.Errorf("invalid idType: must be 'memo_uuid' or 'reference_id'")
+				return
+			}
+		}
+
+		params := url.Values{}
+		if idTypeValue != IDTypeMemoUUID {
+			params.Set("id_type", string(idTypeValue))
+		}
+
+		path := fmt.Sprintf("/api/v1/memo/%s/status/stream", url.PathEscape(memoID))
+		resp, err := c.doRequest(contextWithStreaming(ctx), "GET", path, params, nil)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		defer func() { _ = resp.Body.Close() }() // readSSE drains the body once the stream is done
+
+		if err := c.checkResponse(resp); err != nil {
+			errChan <- err
+			return
+		}
+
+		err = c.readSSE(ctx, resp.Body, func(data string) (bool, error) {
+			var status MemoStatusResponse
+			if err := json.Unmarshal([]byte(data), &status); err != nil {
+				// Skip invalid JSON
+				return true, nil
+			}
+
+			select {
+			case statusChan <- status:
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+
+			return status.Status != MemoStatusProcessed && status.Status != MemoStatusError, nil
+		})
+		if err != nil {
+			errChan <- err
+		}
+	}()
+
+	return statusChan, errChan
+}
+
+// memoProcessingError returns the error for a memo whose processing failed
+func memoProcessingError(status *MemoStatusResponse) error {
+	errMsg := "memo processing failed"
+	if status.ErrorReason != nil {
+		errMsg = *status.ErrorReason
+	}
+	return fmt.Errorf("%s", errMsg)
+}
+
+// AwaitMemoProcessed waits until a memo has been processed, returning an error if
+// processing fails. It is notified by the MemoNotifier set with WithMemoNotifier
+// when there is one, for the lowest latency, and otherwise polls CheckMemoStatus
+// with backoff. If the notifier returns ErrNotifierUnavailable or no final
+// status, it falls back to polling. Notifiers are keyed by memo UUID, so memos
+// identified by reference ID are always polled.
+func (c *Client) AwaitMemoProcessed(ctx context.Context, memoID string, idType ...IDType) error {
+	if c.memoNotifier != nil && (len(idType) == 0 || idType[0] == IDTypeMemoUUID) {
+		status, err := c.memoNotifier.WaitForMemo(ctx, memoID)
+		switch {
+		case errors.Is(err, ErrNotifierUnavailable), err == nil && status == nil:
+			// Fall back to polling
+		case err != nil:
+			return err
+		case status.Readiness() == MemoReadinessError:
+			return memoProcessingError(status)
+		case status.Readiness() == MemoReadinessReady:
+			return nil
+		default:
+			// Not a final status, so fall back to polling
+		}
+	}
+
+	return c.WaitForMemoReadyWithBackoff(ctx, memoID, awaitPollInitialInterval, awaitPollMaxInterval, idType...)
+}
+
+// Search searches for memos
+func (c *Client) Search(ctx context.Context, searchReq SearchRequest) (*SearchResponse, error) {
+	result, err := c.search(ctx, searchReq)
+	if err != nil {
+		return nil, err
+	}
+	if searchReq.MinContentLength != nil {
+		result.Results = filterShortResults(result.Results, *searchReq.MinContentLength)
+	}
+
+	return result, nil
+}
+
+// search runs a search without the client-side MinContentLength filtering, so
+// that the paging helpers can page by the number of results the server returned
+func (c *Client) search(ctx context.Context, searchReq SearchRequest) (*SearchResponse, error) {
+	searchReq.Filters = MergeFilters(c.defaultFilters, searchReq.Filters)
+
+	body, err := json.Marshal(searchReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal search request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/search", nil, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result SearchResponse
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// filterShortResults drops results whose content snippet has fewer than
+// minLength characters
+func filterShortResults(results []SearchResult, minLength int) []SearchResult {
+	filtered := results[:0]
+	for _, r := range results {
+		if !isShortResult(r, minLength) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// isShortResult reports whether the content snippet of r has fewer than
+// minLength characters
+func isShortResult(r SearchResult, minLength int) bool {
+	return utf8.RuneCountInString(r.ContentSnippet) < minLength
+}
+
+// SearchTopN returns up to n results for the search, issuing as many searches as
+// needed to page past the API's per-request limit. searchReq.Limit, if set, is
+// used as the page size. Paging stops early once the API runs out of results,
+// or if it ignores the offset and returns no new results.
+func (c *Client) SearchTopN(ctx context.Context, searchReq SearchRequest, n int) ([]SearchResult, error) {
+	pageSize := searchPageSize(searchReq)
+
+	var results []SearchResult
+	seen := make(map[string]bool)
+	offset := 0
+	for len(results) < n {
+		limit := min(pageSize, n-len(results))
+		pageOffset := offset
+		searchReq.Limit = &limit
+		searchReq.Offset = &pageOffset
+
+		// Page by the results the server returned, and only then drop short
+		// ones, so that the offset isn't thrown off by the filtering
+		resp, err := c.search(ctx, searchReq)
+		if err != nil {
+			return nil, err
+		}
+		offset += len(resp.Results)
+
+		added := 0
+		for _, r := range resp.Results {
+			key := searchResultKey(r)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			added++
+			if searchReq.MinContentLength != nil && isShortResult(r, *searchReq.MinContentLength) {
+				continue
+			}
+			results = append(results, r)
+			if len(results) == n {
+				break
+			}
+		}
+
+		if len(resp.Results) < limit || added == 0 {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// searchResultKey identifies a result when paging, to skip results a server
+// returns again, e.g. because it ignores the offset. Results without a chunk
+// UUID are identified by their memo and snippet instead.
+func searchResultKey(r SearchResult) string {
+	if r.ChunkUUID != "" {
+		return r.ChunkUUID
+	}
+	return r.MemoUUID + "\x00" + r.ContentSnippet
+}
+
+// searchPageSize returns the page size for paging through search results:
+// searchReq.Limit if set, capped at the API's per-request limit
+func searchPageSize(searchReq SearchRequest) int {
+	if searchReq.Limit != nil && *searchReq.Limit > 0 && *searchReq.Limit < maxSearchLimit {
+		return *searchReq.Limit
+	}
+	return maxSearchLimit
+}
+
+// SearchToNDJSON writes every result of the search to w as newline-delimited
+// JSON, one SearchResult per line, e.g. for piping into other tools. Results are
+// written as each page is fetched, paging as SearchTopN does, so the output can
+// be consumed before the export has finished.
+func (c *Client) SearchToNDJSON(ctx context.Context, searchReq SearchRequest, w io.Writer) error {
+	pageSize := searchPageSize(searchReq)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	offset := 0
+	seen := make(map[string]bool)
+	for {
+		limit := pageSize
+		pageOffset := offset
+		searchReq.Limit = &limit
+		searchReq.Offset = &pageOffset
+
+		resp, err := c.search(ctx, searchReq)
+		if err != nil {
+			return err
+		}
+		offset += len(resp.Results)
+
+		added := 0
+		for _, r := range resp.Results {
+			key := searchResultKey(r)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			added++
+			if searchReq.MinContentLength != nil && isShortResult(r, *searchReq.MinContentLength) {
+				continue
+			}
+			if err := encoder.Encode(r); err != nil {
+				return fmt.Errorf("failed to write result: %w", err)
+			}
+		}
+
+		if len(resp.Results) < limit || added == 0 {
+			return nil
+		}
+	}
+}
+
+// SearchAll pages through all results for the search, stopping at maxResults to
+// bound memory on accidentally broad queries. If more results exist beyond the
+// cap, the first maxResults are returned along with ErrSearchTruncated.
+func (c *Client) SearchAll(ctx context.Context, searchReq SearchRequest, maxResults int) ([]SearchResult, error) {
+	if maxResults <= 0 {
+		return nil, fmt.Errorf("maxResults must be positive")
+	}
+
+	// Fetch one extra result to tell whether the cap truncated the results
+	results, err := c.SearchTopN(ctx, searchReq, maxResults+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) > maxResults {
+		return results[:maxResults], ErrSearchTruncated
+	}
+
+	return results, nil
+}
 
-	for {
-		status, err := c.CheckMemoStatus(ctx, memoID, idType...)
-		if err != nil {
-			return err
+// SearchMemos runs the search and returns the full memos of the results instead
+// of snippets. Each memo is fetched once, even if several of its chunks matched,
+// and memos are in the order of their first result. The memos are fetched
+// concurrently; if any fetch fails, its error is returned.
+func (c *Client) SearchMemos(ctx context.Context, searchReq SearchRequest) ([]Memo, error) {
+	resp, err := c.Search(ctx, searchReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var memoUUIDs []string
+	seen := make(map[string]bool)
+	for _, r := range resp.Results {
+		if seen[r.MemoUUID] {
+			continue
 		}
+		seen[r.MemoUUID] = true
+		memoUUIDs = append(memoUUIDs, r.MemoUUID)
+	}
 
-		switch status.Status {
-		case MemoStatusProcessed:
-			return nil
-		case MemoStatusError:
-			errMsg := "memo processing failed"
-			if status.ErrorReason != nil {
-				errMsg = *status.ErrorReason
-			}
-			return fmt.Errorf("%s", errMsg)
-		case MemoStatusProcessing:
-			// Continue polling
+	memos := make([]Memo, len(memoUUIDs))
+	errs := make([]error, len(memoUUIDs))
+	forEachConcurrently(len(memoUUIDs), bulkConcurrency, func(i int) {
+		memo, err := c.GetMemo(ctx, memoUUIDs[i])
+		if err != nil {
+			errs[i] = err
+			return
 		}
+		memos[i] = *memo
+	})
 
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			// Continue to next iteration
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to get memo %s: %w", memoUUIDs[i], err)
 		}
 	}
+
+	return memos, nil
 }
 
-// Search searches for memos
-func (c *Client) Search(ctx context.Context, searchReq SearchRequest) (*SearchResponse, error) {
+// SearchRaw is like Search, but returns the undecoded JSON response body
+func (c *Client) SearchRaw(ctx context.Context, searchReq SearchRequest) (json.RawMessage, error) {
+	searchReq.Filters = MergeFilters(c.defaultFilters, searchReq.Filters)
+
 	body, err := json.Marshal(searchReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal search request: %w", err)
@@ -403,29 +2114,202 @@ func (c *Client) Search(ctx context.Context, searchReq SearchRequest) (*SearchRe
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer drainAndClose(resp.Body)
 
 	if err := c.checkResponse(resp); err != nil {
 		return nil, err
 	}
 
-	var result SearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	var raw json.RawMessage
+	if err := c.decodeJSON(resp.Body, &raw); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &result, nil
+	return raw, nil
 }
 
-// Chat performs a non-streaming chat query and returns the response
-func (c *Client) Chat(ctx context.Context, params ChatParams) (*ChatResponse, error) {
+// PostRaw sends body as a JSON POST request to path (relative to the base URL,
+// e.g. "/api/v1/experimental") and returns the undecoded JSON response body.
+// It is meant for API features the SDK doesn't support yet. An empty response
+// body is returned as nil.
+func (c *Client) PostRaw(ctx context.Context, path string, body json.RawMessage) (json.RawMessage, error) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	resp, err := c.doRequest(ctx, "POST", path, nil, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if err := c.decodeJSON(resp.Body, &raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return raw, nil
+}
+
+// BatchSearch runs multiple searches concurrently, with at most concurrency searches
+// in flight at once. Responses and errors are returned in the same order as the
+// requests; a failed search leaves a zero SearchResponse and a non-nil error at its index.
+func (c *Client) BatchSearch(ctx context.Context, searchReqs []SearchRequest, concurrency int) ([]SearchResponse, []error) {
+	responses := make([]SearchResponse, len(searchReqs))
+	errs := make([]error, len(searchReqs))
+
+	forEachConcurrently(len(searchReqs), concurrency, func(i int) {
+		resp, err := c.Search(ctx, searchReqs[i])
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		responses[i] = *resp
+	})
+
+	return responses, errs
+}
+
+// forEachConcurrently calls fn for each index in [0, n), with at most
+// concurrency calls running at once, and returns when all calls have finished.
+// Fan-out helpers write each result into the slot of its index rather than
+// appending, so that results are in input order regardless of completion order.
+func forEachConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// SearchAndChat searches for query and then chats with retrieval scoped to the
+// memos of the search results, so callers can see which memos fed the answer.
+// searchOpts.Limit controls how many results, and thus memos, are considered.
+// The query overrides the Query of both searchOpts and chatOpts. If the search
+// matches nothing, ErrNoSearchResults is returned along with the search response.
+func (c *Client) SearchAndChat(ctx context.Context, query string, searchOpts SearchRequest, chatOpts ChatParams) (*SearchAndChatResult, error) {
+	searchOpts.Query = query
+	searchResp, err := c.Search(ctx, searchOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SearchAndChatResult{Search: searchResp}
+
+	seen := make(map[string]bool)
+	for _, r := range searchResp.Results {
+		if r.MemoUUID == "" || seen[r.MemoUUID] {
+			continue
+		}
+		seen[r.MemoUUID] = true
+		result.MemoUUIDs = append(result.MemoUUIDs, r.MemoUUID)
+	}
+	if len(result.MemoUUIDs) == 0 {
+		return result, ErrNoSearchResults
+	}
+
+	chatOpts.Query = query
+	chatOpts.MemoUUIDs = result.MemoUUIDs
+	chatOpts.DisableRetrieval = false
+	chatResp, err := c.Chat(ctx, chatOpts)
+	if err != nil {
+		return result, err
+	}
+	result.Chat = chatResp
+
+	return result, nil
+}
+
+// StreamChats runs multiple streaming chats concurrently, with at most concurrency
+// chats in flight at once. Events from all chats are sent on the returned channel,
+// tagged with the index of the chat they belong to; a failed chat sends a single
+// event with Err set. The channel is closed once every chat has finished.
+func (c *Client) StreamChats(ctx context.Context, chats []ChatParams, concurrency int) <-chan IndexedChatStreamEvent {
+	out := make(chan IndexedChatStreamEvent)
+	go func() {
+		defer close(out)
+
+		forEachConcurrently(len(chats), concurrency, func(i int) {
+			eventChan, errChan := c.StreamedChat(ctx, chats[i])
+			for event := range eventChan {
+				select {
+				case out <- IndexedChatStreamEvent{Index: i, Event: event}:
+				case <-ctx.Done():
+				}
+			}
+
+			if err := <-errChan; err != nil {
+				select {
+				case out <- IndexedChatStreamEvent{Index: i, Err: err}:
+				case <-ctx.Done():
+				}
+			}
+		})
+	}()
+
+	return out
+}
+
+// StreamedChatWithCancel is like StreamedChat, but also returns a cancel function
+// that stops the stream, closes the connection and closes both channels.
+// The cancel function must be called once the stream is no longer needed.
+func (c *Client) StreamedChatWithCancel(ctx context.Context, params ChatParams) (<-chan ChatStreamEvent, <-chan error, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	eventChan, errChan := c.StreamedChat(ctx, params)
+	return eventChan, errChan, cancel
+}
+
+// newChatRequest builds the chat request payload from the public chat parameters
+func (c *Client) newChatRequest(params ChatParams, stream bool) (chatRequest, error) {
+	if err := params.Validate(); err != nil {
+		return chatRequest{}, err
+	}
+
 	chatReq := chatRequest{
 		Query:        params.Query,
-		Stream:       false,
+		Stream:       stream,
 		SystemPrompt: params.SystemPrompt,
-		Filters:      params.Filters,
+		Filters:      MergeFilters(c.defaultFilters, params.Filters),
 		ChatID:       params.ChatID,
 		RAGConfig:    params.RAGConfig,
+		MemoUUIDs:    params.MemoUUIDs,
+		Language:     params.Language,
+		IncludeSteps: params.IncludeSteps,
+	}
+
+	// Default filters only scope retrieval, which is skipped when the model
+	// answers directly
+	if params.DisableRetrieval {
+		chatReq.DisableRetrieval = true
+		chatReq.Filters = nil
+	}
+
+	return chatReq, nil
+}
+
+// Chat performs a non-streaming chat query and returns the response
+func (c *Client) Chat(ctx context.Context, params ChatParams) (*ChatResponse, error) {
+	chatReq, err := c.newChatRequest(params, false)
+	if err != nil {
+		return nil, err
 	}
 
 	body, err := json.Marshal(chatReq)
@@ -437,16 +2321,20 @@ func (c *Client) Chat(ctx context.Context, params ChatParams) (*ChatResponse, er
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer drainAndClose(resp.Body)
 
 	if err := c.checkResponse(resp); err != nil {
 		return nil, err
 	}
 
 	var result ChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	// Servers that don't know the flag still return the steps
+	if params.IncludeSteps != nil && !*params.IncludeSteps {
+		result.IntermediateSteps = nil
+	}
 
 	return &result, nil
 }
@@ -460,13 +2348,10 @@ func (c *Client) StreamedChat(ctx context.Context, params ChatParams) (<-chan Ch
 		defer close(eventChan)
 		defer close(errChan)
 
-		chatReq := chatRequest{
-			Query:        params.Query,
-			Stream:       true,
-			SystemPrompt: params.SystemPrompt,
-			Filters:      params.Filters,
-			ChatID:       params.ChatID,
-			RAGConfig:    params.RAGConfig,
+		chatReq, err := c.newChatRequest(params, true)
+		if err != nil {
+			errChan <- err
+			return
 		}
 
 		body, err := json.Marshal(chatReq)
@@ -475,19 +2360,19 @@ func (c *Client) StreamedChat(ctx context.Context, params ChatParams) (<-chan Ch
 			return
 		}
 
-		resp, err := c.doRequest(ctx, "POST", "/api/v1/chat", nil, bytes.NewReader(body))
+		resp, err := c.doRequest(contextWithStreaming(ctx), "POST", "/api/v1/chat", nil, bytes.NewReader(body))
 		if err != nil {
 			errChan <- err
 			return
 		}
-		defer func() { _ = resp.Body.Close() }()
+		defer func() { _ = resp.Body.Close() }() // parseSSEStream drains the body once the stream is done
 
 		if err := c.checkResponse(resp); err != nil {
 			errChan <- err
 			return
 		}
 
-		if err := c.parseSSEStream(resp.Body, eventChan); err != nil {
+		if err := c.parseSSEStream(ctx, resp.Body, eventChan); err != nil {
 			errChan <- err
 			return
 		}
@@ -496,8 +2381,80 @@ func (c *Client) StreamedChat(ctx context.Context, params ChatParams) (<-chan Ch
 	return eventChan, errChan
 }
 
+// StreamRaw performs a streaming chat query and returns the live SSE response
+// body, for callers that parse the events themselves. The caller must close it.
+func (c *Client) StreamRaw(ctx context.Context, params ChatParams) (io.ReadCloser, error) {
+	chatReq, err := c.newChatRequest(params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chat request: %w", err)
+	}
+
+	resp, err := c.doRequest(contextWithStreaming(ctx), "POST", "/api/v1/chat", nil, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkResponse(resp); err != nil {
+		drainAndClose(resp.Body)
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// CollectChatStream drains the channels returned by StreamedChat and accumulates
+// the tokens, references and final "done" metadata into a single result.
+// References from multiple events are merged.
+func CollectChatStream(eventChan <-chan ChatStreamEvent, errChan <-chan error) (*ChatStreamResult, error) {
+	var response strings.Builder
+	result := &ChatStreamResult{}
+
+	for event := range eventChan {
+		switch event.Type {
+		case "token":
+			if event.Content != nil {
+				response.WriteString(*event.Content)
+			}
+		case "references":
+			// References may arrive incrementally, as JSON in the content
+			if event.Content != nil {
+				var refs References
+				if err := json.Unmarshal([]byte(*event.Content), &refs); err == nil {
+					result.References = MergeReferences(result.References, refs)
+					continue
+				}
+			}
+			result.References = MergeReferences(result.References, event.References)
+		case "done":
+			if event.ChatID != "" {
+				result.ChatID = event.ChatID
+			}
+			result.References = MergeReferences(result.References, event.References)
+			result.Usage = event.Usage
+			result.Model = event.Model
+		}
+	}
+
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+
+	result.Response = response.String()
+	return result, nil
+}
+
 // doRequest performs an HTTP request
 func (c *Client) doRequest(ctx context.Context, method, path string, params url.Values, body io.Reader) (*http.Response, error) {
+	return c.doRequestWithHeaders(ctx, method, path, params, body, nil)
+}
+
+// doRequestWithHeaders performs an HTTP request with additional headers
+func (c *Client) doRequestWithHeaders(ctx context.Context, method, path string, params url.Values, body io.Reader, headers http.Header) (*http.Response, error) {
 	urlStr := c.baseURL + path
 	if len(params) > 0 {
 		urlStr += "?" + params.Encode()
@@ -508,12 +2465,203 @@ func (c *Client) doRequest(ctx context.Context, method, path string, params url.
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	return c.do(req)
+}
+
+// do sets the headers common to all requests, applies the default context
+// timeout and executes the request
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.apiKeyErr != nil {
+		return nil, c.apiKeyErr
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	correlationID := CorrelationIDFromContext(req.Context())
+	if correlationID == "" && c.correlationIDs {
+		correlationID = uuid.NewString()
+	}
+	if correlationID != "" {
+		req.Header.Set(correlationIDHeader, correlationID)
+	}
+
+	// Bound requests without a deadline by the default timeout, keeping the
+	// context alive until the response body is closed
+	if _, ok := req.Context().Deadline(); !ok && c.defaultContextTimeout > 0 && !isStreamingContext(req.Context()) {
+		ctx, cancel := context.WithTimeout(req.Context(), c.defaultContextTimeout)
+		resp, err := c.send(req.WithContext(ctx))
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+
+	return c.send(req)
+}
+
+// send executes the request, retrying it as configured with WithRetries and
+// failing fast while the circuit breaker is open
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.breaker == nil {
+		return c.sendWithRetries(req)
+	}
+
+	if err := c.breaker.allow(c.clock.Now()); err != nil {
+		return nil, err
+	}
+	resp, err := c.sendWithRetries(req)
+	c.breaker.record(c.clock.Now(), resp, err)
+	return resp, err
+}
+
+// sendWithRetries executes the request, retrying it as configured with WithRetries
+func (c *Client) sendWithRetries(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	for attempt := 0; attempt < c.maxRetries; attempt++ {
+		if err != nil || !c.shouldRetry(req, resp) {
+			break
+		}
+		retryReq, ok := newRetryRequest(req)
+		if !ok {
+			break
+		}
+
+		delay := c.retryDelay(attempt, resp)
+		drainAndClose(resp.Body)
+		if err := c.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		req = retryReq
+		resp, err = c.httpClient.Do(req)
+	}
+
+	return resp, err
+}
+
+// streamingContextKey is the context key marking requests for streamed responses
+type streamingContextKey struct{}
+
+// contextWithStreaming marks ctx as belonging to a streamed response, which is
+// exempt from the default context timeout
+func contextWithStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingContextKey{}, true)
+}
+
+// isStreamingContext reports whether ctx was marked with contextWithStreaming
+func isStreamingContext(ctx context.Context) bool {
+	streaming, _ := ctx.Value(streamingContextKey{}).(bool)
+	return streaming
+}
+
+// cancelOnCloseBody cancels a request's context once its response body is closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// drainAndClose reads the rest of body and closes it so the connection can be
+// reused. Response bodies are always closed this way, since a JSON decoder may
+// stop before the end of the body (e.g. at trailing whitespace) and error
+// responses may be left partially read.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainSize))
+	_ = body.Close()
+}
+
+// validateMetadata checks metadata against the schema set with WithMetadataSchema,
+// rejecting undeclared keys and values of the wrong kind. Nil values are allowed,
+// and numeric kinds are interchangeable since they are all sent as JSON numbers.
+func (c *Client) validateMetadata(metadata map[string]interface{}) error {
+	if c.metadataSchema == nil {
+		return nil
+	}
+
+	for key, value := range metadata {
+		kind, ok := c.metadataSchema[key]
+		if !ok {
+			return fmt.Errorf("%w: undeclared key %q", ErrInvalidMetadata, key)
+		}
+		if value == nil {
+			continue
+		}
+		actual := reflect.TypeOf(value).Kind()
+		if actual != kind && !(isNumericKind(actual) && isNumericKind(kind)) {
+			return fmt.Errorf("%w: key %q must be of kind %s, got %s", ErrInvalidMetadata, key, kind, actual)
+		}
+	}
+	return nil
+}
+
+// isNumericKind reports whether kind is an integer or floating-point kind
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// decodeJSON decodes a JSON response body into v, rejecting unknown fields
+// when strict decoding is enabled
+func (c *Client) decodeJSON(body io.Reader, v interface{}) error {
+	return c.newDecoder(body).Decode(v)
+}
+
+// newDecoder returns a JSON decoder for a response body that enforces the
+// maximum response size and strict decoding when enabled
+func (c *Client) newDecoder(body io.Reader) *json.Decoder {
+	if c.maxResponseSize > 0 {
+		body = &maxBytesReader{r: body, remaining: c.maxResponseSize}
+	}
+
+	decoder := json.NewDecoder(body)
+	if c.strictDecoding {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder
+}
+
+// maxBytesReader reads from r until more than remaining bytes have been read,
+// after which it fails with ErrResponseTooLarge
+type maxBytesReader struct {
+	r         io.Reader
+	remaining int64
+}
 
-	return c.httpClient.Do(req)
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	// Read at most one byte past the limit to detect oversized bodies
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+	n, err := m.r.Read(p)
+	m.remaining -= int64(n)
+	if m.remaining < 0 {
+		return n, ErrResponseTooLarge
+	}
+	return n, err
 }
 
 // checkResponse checks if the HTTP response indicates an error
@@ -522,45 +2670,206 @@ func (c *Client) checkResponse(resp *http.Response) error {
 		return nil
 	}
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	return &APIError{
+	var body io.Reader = resp.Body
+	if c.maxResponseSize > 0 {
+		body = &maxBytesReader{r: body, remaining: c.maxResponseSize}
+	}
+	bodyBytes, err := io.ReadAll(body)
+	if errors.Is(err, ErrResponseTooLarge) {
+		// Keep reporting the API error, with its body cut at the limit
+		bodyBytes = bodyBytes[:min(int64(len(bodyBytes)), c.maxResponseSize)]
+	}
+	message := string(bodyBytes)
+	if resp.StatusCode == http.StatusRequestEntityTooLarge {
+		message = "payload too large: uploads are limited to 100MB"
+		if len(bodyBytes) > 0 {
+			message += ": " + string(bodyBytes)
+		}
+	}
+
+	apiErr := &APIError{
 		StatusCode: resp.StatusCode,
-		Message:    string(bodyBytes),
+		Message:    message,
+		Code:       parseErrorCode(bodyBytes),
+	}
+	if resp.Request != nil {
+		apiErr.CorrelationID = resp.Request.Header.Get(correlationIDHeader)
+	}
+
+	return apiErr
+}
+
+// parseErrorCode extracts the machine-readable error code from an error response body
+func parseErrorCode(body []byte) string {
+	var errBody struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(body, &errBody); err != nil {
+		return ""
+	}
+	return errBody.Code
+}
+
+// parseErrorReferenceID extracts the conflicting reference ID from an error
+// response body, if the server reports one
+func parseErrorReferenceID(body []byte) string {
+	var errBody struct {
+		ReferenceID string `json:"reference_id"`
+	}
+	if err := json.Unmarshal(body, &errBody); err != nil {
+		return ""
+	}
+	return errBody.ReferenceID
+}
+
+// duplicateReferenceIDError turns a 409 Conflict error from creating a memo into
+// a *DuplicateReferenceIDError. The reference ID reported by the server is
+// preferred over the one sent; if neither is known, err is returned unchanged.
+func duplicateReferenceIDError(err error, referenceID *string) error {
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		return err
+	}
+
+	conflicting := parseErrorReferenceID([]byte(apiErr.Message))
+	if conflicting == "" && referenceID != nil {
+		conflicting = *referenceID
+	}
+	if conflicting == "" {
+		return err
+	}
+
+	return &DuplicateReferenceIDError{ReferenceID: conflicting, Err: apiErr}
+}
+
+// startHeartbeats sends a "heartbeat" event on eventChan every heartbeat interval
+// configured with WithStreamHeartbeat, until the returned function is called.
+// The returned function waits for the heartbeat goroutine to exit, so no
+// heartbeat is sent after it returns, and may be called more than once.
+func (c *Client) startHeartbeats(ctx context.Context, eventChan chan<- ChatStreamEvent) func() {
+	if c.streamHeartbeat <= 0 {
+		return func() {}
+	}
+
+	ticks, stopTicker := c.clock.NewTicker(c.streamHeartbeat)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer stopTicker()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticks:
+				select {
+				case eventChan <- ChatStreamEvent{Type: "heartbeat"}:
+				case <-stop:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+		<-stopped
 	}
 }
 
-// parseSSEStream parses Server-Sent Events stream
-func (c *Client) parseSSEStream(body io.Reader, eventChan chan<- ChatStreamEvent) error {
+// sseData returns the value of an SSE "data" field. As per the SSE spec, the
+// "data:" prefix may be followed by a single optional space which is not part
+// of the value.
+func sseData(line string) (string, bool) {
+	after, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimPrefix(after, " "), true
+}
+
+// readSSE calls handle with the data of each event in a Server-Sent Events
+// stream until handle returns false or the stream ends. Comment lines are pings,
+// which are only reported to the ping handler set with WithStreamPingHandler.
+func (c *Client) readSSE(ctx context.Context, body io.Reader, handle func(data string) (bool, error)) error {
 	scanner := bufio.NewScanner(body)
+	lastPing := c.clock.Now()
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		// Skip empty lines and ping lines
-		if line == "" || strings.HasPrefix(line, ":") {
+		// Skip empty lines
+		if line == "" {
 			continue
 		}
 
-		// Parse data lines
-		if after, ok := strings.CutPrefix(line, "data: "); ok {
-			var event ChatStreamEvent
-			if err := json.Unmarshal([]byte(after), &event); err != nil {
-				// Skip invalid JSON
-				continue
+		if comment, ok := strings.CutPrefix(line, ":"); ok {
+			if c.streamPingHandler != nil {
+				now := c.clock.Now()
+				c.streamPingHandler(StreamPing{
+					Comment:    strings.TrimSpace(comment),
+					ReceivedAt: now,
+					Interval:   now.Sub(lastPing),
+				})
+				lastPing = now
 			}
+			continue
+		}
 
-			eventChan <- event
-
-			// Stop on 'done' event
-			if event.Type == "done" {
+		// Parse data lines
+		if data, ok := sseData(line); ok {
+			more, err := handle(data)
+			if err != nil {
+				return err
+			}
+			if !more {
+				// Drain any trailing data so the connection can be reused
+				_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainSize))
 				return nil
 			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		return fmt.Errorf("error reading stream: %w", err)
 	}
 
 	return nil
 }
+
+// parseSSEStream parses Server-Sent Events stream, stopping early if ctx is cancelled
+func (c *Client) parseSSEStream(ctx context.Context, body io.Reader, eventChan chan<- ChatStreamEvent) error {
+	stopHeartbeats := c.startHeartbeats(ctx, eventChan)
+	defer stopHeartbeats()
+
+	return c.readSSE(ctx, body, func(data string) (bool, error) {
+		var event ChatStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			// Skip invalid JSON
+			return true, nil
+		}
+
+		// No heartbeats may follow the 'done' event
+		if event.Type == "done" {
+			stopHeartbeats()
+		}
+
+		select {
+		case eventChan <- event:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+
+		// Stop on 'done' event
+		return event.Type != "done", nil
+	})
+}
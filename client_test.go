@@ -2,10 +2,18 @@ package skald
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -77,6 +85,29 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestNewClientTrimsAPIKey(t *testing.T) {
+	var authHeader string
+	client := NewClient("sk_live_0123456789abcdef\n")
+	client.httpClient = &http.Client{Transport: &mockRoundTripper{roundTripFunc: func(req *http.Request) (*http.Response, error) {
+		authHeader = req.Header.Get("Authorization")
+		return mockResponse(200, `{"uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	}}}
+
+	if _, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if authHeader != "Bearer sk_live_0123456789abcdef" {
+		t.Errorf("expected clean Authorization header, got %q", authHeader)
+	}
+
+	if client := NewClient("  sk_live_0123456789abcdef\r\n"); client.apiKey != "sk_live_0123456789abcdef" {
+		t.Errorf("expected trimmed key, got %q", client.apiKey)
+	}
+	if client := NewClient("sk_live_0123456789abcdef"); client.apiKey != "sk_live_0123456789abcdef" {
+		t.Errorf("expected valid key to be unchanged, got %q", client.apiKey)
+	}
+}
+
 func TestCreateMemo(t *testing.T) {
 	client := newMockClient(func(req *http.Request) (*http.Response, error) {
 		if req.Method != "POST" {
@@ -104,6 +135,136 @@ func TestCreateMemo(t *testing.T) {
 	}
 }
 
+func TestCreateMemoAndGet(t *testing.T) {
+	var requests []string
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		switch req.Method {
+		case "POST":
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		case "GET":
+			return mockResponse(200, `{
+				"uuid": "123e4567-e89b-12d3-a456-426614174000",
+				"title": "Test Memo",
+				"content": "This is test content",
+				"pending": true
+			}`), nil
+		}
+		return mockResponse(405, `{"error": "method not allowed"}`), nil
+	})
+
+	memo, err := client.CreateMemoAndGet(context.Background(), MemoData{
+		Title:   "Test Memo",
+		Content: "This is test content",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"POST /api/v1/memo",
+		"GET /api/v1/memo/123e4567-e89b-12d3-a456-426614174000",
+	}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+	if memo.UUID != "123e4567-e89b-12d3-a456-426614174000" || memo.Title != "Test Memo" || !memo.Pending {
+		t.Errorf("unexpected memo: %+v", memo)
+	}
+}
+
+func TestCreateMemoAndGetFetchError(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == "POST" {
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		}
+		return mockResponse(500, `{"error": "internal"}`), nil
+	})
+
+	_, err := client.CreateMemoAndGet(context.Background(), MemoData{Title: "Test Memo", Content: "content"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "123e4567-e89b-12d3-a456-426614174000") {
+		t.Errorf("expected error to mention the created memo UUID, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Errorf("expected wrapped APIError, got %v", err)
+	}
+}
+
+func TestCreateMemoDuplicateReferenceID(t *testing.T) {
+	tests := []struct {
+		name         string
+		responseBody string
+		expectedRef  string
+	}{
+		{"reference ID from response", `{"error": "Reference ID already exists", "reference_id": "doc-42-v2"}`, "doc-42-v2"},
+		{"reference ID from request", `{"error": "Reference ID already exists"}`, "doc-42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				return mockResponse(409, tt.responseBody), nil
+			})
+
+			referenceID := "doc-42"
+			_, err := client.CreateMemo(context.Background(), MemoData{
+				Title:       "Test Memo",
+				Content:     "content",
+				ReferenceID: &referenceID,
+			})
+
+			var dupErr *DuplicateReferenceIDError
+			if !errors.As(err, &dupErr) {
+				t.Fatalf("expected DuplicateReferenceIDError, got %v", err)
+			}
+			if !dupErr.IsConflict() {
+				t.Error("expected IsConflict to be true")
+			}
+			if dupErr.ReferenceID != tt.expectedRef {
+				t.Errorf("expected reference ID %q, got %q", tt.expectedRef, dupErr.ReferenceID)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || !apiErr.IsConflict() {
+				t.Errorf("expected wrapped conflict APIError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateMemoConflictWithoutReferenceID(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(409, `{"error": "Conflict"}`), nil
+	})
+
+	_, err := client.CreateMemo(context.Background(), MemoData{Title: "Test Memo", Content: "content"})
+	var dupErr *DuplicateReferenceIDError
+	if errors.As(err, &dupErr) {
+		t.Fatalf("expected plain APIError without a reference ID, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsConflict() {
+		t.Errorf("expected conflict APIError, got %v", err)
+	}
+}
+
+func TestCreateMemoFromReaderDuplicateReferenceID(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(409, `{"error": "Reference ID already exists"}`), nil
+	})
+
+	referenceID := "file-7"
+	_, err := client.CreateMemoFromReader(context.Background(), strings.NewReader("data"), "doc.pdf", &MemoFileData{ReferenceID: &referenceID})
+	var dupErr *DuplicateReferenceIDError
+	if !errors.As(err, &dupErr) || dupErr.ReferenceID != "file-7" {
+		t.Fatalf("expected DuplicateReferenceIDError for file-7, got %v", err)
+	}
+}
+
 func TestCreateMemoInitializesMetadata(t *testing.T) {
 	var capturedBody []byte
 	client := newMockClient(func(req *http.Request) (*http.Response, error) {
@@ -300,6 +461,53 @@ func TestDeleteMemo(t *testing.T) {
 	}
 }
 
+func TestDeleteMemoWithOptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           DeleteMemoOptions
+		idType         []IDType
+		expectedParams string
+	}{
+		{
+			name:           "soft",
+			opts:           DeleteMemoOptions{},
+			expectedParams: "",
+		},
+		{
+			name:           "hard",
+			opts:           DeleteMemoOptions{Hard: true},
+			expectedParams: "hard=true",
+		},
+		{
+			name:           "hard by reference ID",
+			opts:           DeleteMemoOptions{Hard: true},
+			idType:         []IDType{IDTypeReferenceID},
+			expectedParams: "hard=true&id_type=reference_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				if req.Method != "DELETE" {
+					t.Errorf("expected DELETE request, got %s", req.Method)
+				}
+				if req.URL.Path != "/api/v1/memo/test-id" {
+					t.Errorf("expected path /api/v1/memo/test-id, got %s", req.URL.Path)
+				}
+				if req.URL.RawQuery != tt.expectedParams {
+					t.Errorf("expected params %s, got %s", tt.expectedParams, req.URL.RawQuery)
+				}
+				return mockResponse(204, ``), nil
+			})
+
+			if err := client.DeleteMemoWithOptions(context.Background(), "test-id", tt.opts, tt.idType...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
 func TestSearch(t *testing.T) {
 	client := newMockClient(func(req *http.Request) (*http.Response, error) {
 		if req.Method != "POST" {
@@ -339,6 +547,45 @@ func TestSearch(t *testing.T) {
 	}
 }
 
+func TestSearchMinContentLength(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if body["min_content_length"] != float64(10) {
+			t.Errorf("expected min_content_length 10, got %v", body["min_content_length"])
+		}
+		// The server ignores the minimum length, so short snippets are returned
+		return mockResponse(200, `{
+			"results": [
+				{"chunk_uuid": "chunk-1", "content_snippet": "A sufficiently long snippet"},
+				{"chunk_uuid": "chunk-2", "content_snippet": "Too short"},
+				{"chunk_uuid": "chunk-3", "content_snippet": "Ünïcödé ñ"},
+				{"chunk_uuid": "chunk-4", "content_snippet": "Exactly 10"}
+			]
+		}`), nil
+	})
+
+	minLength := 10
+	resp, err := client.Search(context.Background(), SearchRequest{
+		Query:            "test query",
+		MinContentLength: &minLength,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var chunks []string
+	for _, r := range resp.Results {
+		chunks = append(chunks, r.ChunkUUID)
+	}
+	expected := []string{"chunk-1", "chunk-4"}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected results %v, got %v", expected, chunks)
+	}
+}
+
 func TestSearchWithFilters(t *testing.T) {
 	var capturedBody []byte
 	client := newMockClient(func(req *http.Request) (*http.Response, error) {
@@ -513,6 +760,260 @@ data: {"type":"done"}
 	}
 }
 
+// trackingBody records whether a response body was read to EOF and closed
+type trackingBody struct {
+	reader  io.Reader
+	drained atomic.Bool
+	closed  atomic.Bool
+}
+
+func (b *trackingBody) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	if err == io.EOF {
+		b.drained.Store(true)
+	}
+	return n, err
+}
+
+func (b *trackingBody) Close() error {
+	b.closed.Store(true)
+	return nil
+}
+
+func TestStreamedChatDrainsTrailingData(t *testing.T) {
+	sseData := "data: {\"type\":\"token\",\"content\":\"Hello\"}\n" +
+		"data: {\"type\":\"done\"}\n" +
+		"data: {\"type\":\"token\",\"content\":\"trailing\"}\n" +
+		": trailer\n" +
+		strings.Repeat("x", 8192) + "\n"
+
+	body := &trackingBody{reader: strings.NewReader(sseData)}
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       body,
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	eventChan, errChan := client.StreamedChat(context.Background(), ChatParams{
+		Query: "test query",
+	})
+
+	var events []ChatStreamEvent
+	for event := range eventChan {
+		events = append(events, event)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[1].Type != "done" {
+		t.Errorf("expected last event to be done, got %s", events[1].Type)
+	}
+	if !body.drained.Load() {
+		t.Error("expected body to be drained after done event")
+	}
+	if !body.closed.Load() {
+		t.Error("expected body to be closed")
+	}
+}
+
+// slowStreamHandler streams a token, then only ping lines for a while, then done
+func slowStreamHandler(pings int, pingInterval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		_, _ = io.WriteString(w, "data: {\"type\":\"token\",\"content\":\"Thinking\"}\n\n")
+		flusher.Flush()
+		for i := 0; i < pings; i++ {
+			time.Sleep(pingInterval)
+			_, _ = io.WriteString(w, ": ping\n\n")
+			flusher.Flush()
+		}
+		_, _ = io.WriteString(w, "data: {\"type\":\"done\"}\n\n")
+	}
+}
+
+// blockingStreamHandler streams a token, then blocks until release is closed
+// before sending done
+func blockingStreamHandler(release <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "data: {\"type\":\"token\",\"content\":\"Thinking\"}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = io.WriteString(w, "data: {\"type\":\"done\"}\n\n")
+	}
+}
+
+func TestStreamedChatHeartbeat(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(blockingStreamHandler(release))
+	defer server.Close()
+	var once sync.Once
+	defer once.Do(func() { close(release) })
+
+	clk := newFakeClock()
+	client := NewClientWithOptions("test-api-key", WithBaseURL(server.URL), WithStreamHeartbeat(10*time.Second), withClock(clk))
+	eventChan, errChan := client.StreamedChat(context.Background(), ChatParams{Query: "test query"})
+
+	types := []string{(<-eventChan).Type}
+	for i := 0; i < 2; i++ {
+		clk.Tick()
+		types = append(types, (<-eventChan).Type)
+	}
+	once.Do(func() { close(release) })
+	for event := range eventChan {
+		types = append(types, event.Type)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"token", "heartbeat", "heartbeat", "done"}; !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected events %v, got %v", expected, types)
+	}
+	if clk.Tickers() != 0 {
+		t.Errorf("expected the heartbeat ticker to be stopped, got %d running", clk.Tickers())
+	}
+}
+
+func TestStreamedChatNoHeartbeatByDefault(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(blockingStreamHandler(release))
+	defer server.Close()
+	var once sync.Once
+	defer once.Do(func() { close(release) })
+
+	clk := newFakeClock()
+	client := NewClientWithOptions("test-api-key", WithBaseURL(server.URL), withClock(clk))
+	eventChan, errChan := client.StreamedChat(context.Background(), ChatParams{Query: "test query"})
+
+	if event := <-eventChan; event.Type != "token" {
+		t.Fatalf("expected token event, got %s", event.Type)
+	}
+	if clk.Tickers() != 0 {
+		t.Errorf("expected no heartbeat ticker by default, got %d", clk.Tickers())
+	}
+	clk.Tick()
+	once.Do(func() { close(release) })
+
+	for event := range eventChan {
+		if event.Type == "heartbeat" {
+			t.Error("expected no heartbeat events by default")
+		}
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStreamedChatPingHandler(t *testing.T) {
+	server := httptest.NewServer(slowStreamHandler(3, 10*time.Millisecond))
+	defer server.Close()
+
+	var pings []StreamPing
+	client := NewClientWithOptions("test-api-key", WithBaseURL(server.URL), WithStreamPingHandler(func(ping StreamPing) {
+		pings = append(pings, ping)
+	}))
+	eventChan, errChan := client.StreamedChat(context.Background(), ChatParams{Query: "test query"})
+
+	var events []ChatStreamEvent
+	for event := range eventChan {
+		events = append(events, event)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pings) != 3 {
+		t.Fatalf("expected 3 pings, got %d", len(pings))
+	}
+	for i, ping := range pings {
+		if ping.Comment != "ping" {
+			t.Errorf("ping %d: expected comment 'ping', got %q", i, ping.Comment)
+		}
+		if ping.ReceivedAt.IsZero() {
+			t.Errorf("ping %d: expected a timestamp", i)
+		}
+		if ping.Interval < 5*time.Millisecond {
+			t.Errorf("ping %d: expected interval of about 10ms, got %v", i, ping.Interval)
+		}
+		if i > 0 && ping.ReceivedAt.Before(pings[i-1].ReceivedAt) {
+			t.Errorf("ping %d: expected increasing timestamps", i)
+		}
+	}
+	if len(events) != 2 {
+		t.Errorf("expected pings to stay out of the event channel, got %d events", len(events))
+	}
+}
+
+func TestStreamedChatDataPrefixWithoutSpace(t *testing.T) {
+	collect := func(sseData string) []ChatStreamEvent {
+		client := newMockClient(func(req *http.Request) (*http.Response, error) {
+			return mockResponse(200, sseData), nil
+		})
+
+		eventChan, errChan := client.StreamedChat(context.Background(), ChatParams{
+			Query: "test query",
+		})
+
+		var events []ChatStreamEvent
+		for event := range eventChan {
+			events = append(events, event)
+		}
+		if err := <-errChan; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return events
+	}
+
+	withSpace := collect("data: {\"type\":\"token\",\"content\":\"Hello\"}\ndata: {\"type\":\"done\"}\n")
+	withoutSpace := collect("data:{\"type\":\"token\",\"content\":\"Hello\"}\ndata:{\"type\":\"done\"}\n")
+
+	if len(withSpace) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(withSpace))
+	}
+	if len(withoutSpace) != len(withSpace) {
+		t.Fatalf("expected %d events without space, got %d", len(withSpace), len(withoutSpace))
+	}
+	for i := range withSpace {
+		if withSpace[i].Type != withoutSpace[i].Type {
+			t.Errorf("event %d: expected type %s, got %s", i, withSpace[i].Type, withoutSpace[i].Type)
+		}
+	}
+	if withoutSpace[0].Content == nil || *withoutSpace[0].Content != "Hello" {
+		t.Error("expected token content 'Hello'")
+	}
+}
+
+func TestSSEData(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+		ok       bool
+	}{
+		{line: "data: x", expected: "x", ok: true},
+		{line: "data:x", expected: "x", ok: true},
+		{line: "data:  x", expected: " x", ok: true},
+		{line: "data:", expected: "", ok: true},
+		{line: "event: x", expected: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := sseData(tt.line)
+		if ok != tt.ok || got != tt.expected {
+			t.Errorf("sseData(%q) = %q, %v; expected %q, %v", tt.line, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
 func TestStreamedChatWithPingLines(t *testing.T) {
 	sseData := `: ping
 data: {"type":"token","content":"Hello"}
@@ -621,26 +1122,228 @@ func TestMemoWithAllFields(t *testing.T) {
 	}
 }
 
-func TestCreateMemoFromFile(t *testing.T) {
-	// Create a temporary test file
-	tmpFile, err := os.CreateTemp("", "test-*.pdf")
+func TestCreateMemoWithEmbeddingModel(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if body["embedding_model"] != "text-embedding-3-large" {
+			t.Errorf("expected embedding_model text-embedding-3-large, got %v", body["embedding_model"])
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	model := "text-embedding-3-large"
+	_, err := client.CreateMemo(context.Background(), MemoData{
+		Title:          "Test Memo",
+		Content:        "This is test content",
+		EmbeddingModel: &model,
+	})
 	if err != nil {
-		t.Fatalf("failed to create temp file: %v", err)
+		t.Fatalf("unexpected error: %v", err)
 	}
-	defer func() { _ = os.Remove(tmpFile.Name()) }()
 
-	// Write some test content
-	content := []byte("test PDF content")
-	if _, err := tmpFile.Write(content); err != nil {
-		t.Fatalf("failed to write to temp file: %v", err)
-	}
-	if err := tmpFile.Close(); err != nil {
-		t.Fatalf("failed to close temp file: %v", err)
+	// The field is omitted when not set
+	client = newMockClient(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		if strings.Contains(string(body), "embedding_model") {
+			t.Errorf("expected no embedding_model in body, got %s", body)
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+	if _, err := client.CreateMemo(context.Background(), MemoData{Title: "Test Memo", Content: "content"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
+}
 
+func TestCreateMemoFromReaderWithEmbeddingModel(t *testing.T) {
 	client := newMockClient(func(req *http.Request) (*http.Response, error) {
-		if req.Method != "POST" {
-			t.Errorf("expected POST request, got %s", req.Method)
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse multipart form: %v", err)
+		}
+		if req.FormValue("embedding_model") != "text-embedding-3-large" {
+			t.Errorf("expected embedding_model field, got %q", req.FormValue("embedding_model"))
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	model := "text-embedding-3-large"
+	_, err := client.CreateMemoFromReader(context.Background(), strings.NewReader("content"), "notes.txt", &MemoFileData{
+		EmbeddingModel: &model,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCreateMemoFromReaderExpirationDateUTC(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse multipart form: %v", err)
+		}
+		if req.FormValue("expiration_date") != "2026-06-01T14:30:00Z" {
+			t.Errorf("expected expiration_date in UTC, got %q", req.FormValue("expiration_date"))
+		}
+		if req.FormValue("metadata") != `{"due":"2026-06-01T14:30:00Z"}` {
+			t.Errorf("expected metadata times in UTC, got %q", req.FormValue("metadata"))
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	expiration := time.Date(2026, 6, 1, 9, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	_, err := client.CreateMemoFromReader(context.Background(), strings.NewReader("content"), "notes.txt", &MemoFileData{
+		ExpirationDate: &expiration,
+		Metadata:       map[string]interface{}{"due": expiration},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCreateMemoWithChunkConfig(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var body map[string]json.RawMessage
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		expected := `{"size":500,"overlap":0,"strategy":"paragraph"}`
+		if string(body["chunk_config"]) != expected {
+			t.Errorf("expected chunk_config %s, got %s", expected, body["chunk_config"])
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	overlap := 0
+	_, err := client.CreateMemo(context.Background(), MemoData{
+		Title:       "Test Memo",
+		Content:     "content",
+		ChunkConfig: &ChunkConfig{Size: 500, Overlap: &overlap, Strategy: ChunkStrategyParagraph},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCreateMemoFromReaderWithChunkConfig(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse multipart form: %v", err)
+		}
+		expected := `{"size":800,"strategy":"sentence"}`
+		if req.FormValue("chunk_config") != expected {
+			t.Errorf("expected chunk_config field %s, got %q", expected, req.FormValue("chunk_config"))
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	_, err := client.CreateMemoFromReader(context.Background(), strings.NewReader("content"), "notes.txt", &MemoFileData{
+		ChunkConfig: &ChunkConfig{Size: 800, Strategy: ChunkStrategySentence},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCreateMemoFromReaderFixedBoundary(t *testing.T) {
+	var bodies []string
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if contentType := req.Header.Get("Content-Type"); contentType != "multipart/form-data; boundary=skald-test-boundary" {
+			t.Errorf("unexpected Content-Type %q", contentType)
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		bodies = append(bodies, string(body))
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+	withMultipartBoundary("skald-test-boundary")(client)
+
+	title := "Notes"
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateMemoFromReader(context.Background(), strings.NewReader("content"), "notes.txt", &MemoFileData{
+			Title: &title,
+			Tags:  []string{"a", "b"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	golden := "--skald-test-boundary\r\n" +
+		"Content-Disposition: form-data; name=\"file\"; filename=\"notes.txt\"\r\n" +
+		"Content-Type: application/octet-stream\r\n" +
+		"\r\n" +
+		"content\r\n" +
+		"--skald-test-boundary\r\n" +
+		"Content-Disposition: form-data; name=\"title\"\r\n" +
+		"\r\n" +
+		"Notes\r\n" +
+		"--skald-test-boundary\r\n" +
+		"Content-Disposition: form-data; name=\"tags\"\r\n" +
+		"\r\n" +
+		"[\"a\",\"b\"]\r\n" +
+		"--skald-test-boundary--\r\n"
+	for i, body := range bodies {
+		if body != golden {
+			t.Errorf("upload %d: expected body %q, got %q", i, golden, body)
+		}
+	}
+}
+
+func TestCreateMemoFromReaderRandomBoundary(t *testing.T) {
+	var contentTypes []string
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		contentTypes = append(contentTypes, req.Header.Get("Content-Type"))
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.CreateMemoFromReader(context.Background(), strings.NewReader("content"), "notes.txt", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if contentTypes[0] == contentTypes[1] {
+		t.Errorf("expected a random boundary per upload, got %q twice", contentTypes[0])
+	}
+}
+
+func TestCreateMemoFromReaderInvalidBoundary(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		t.Error("expected no request")
+		return nil, nil
+	})
+	withMultipartBoundary("invalid boundary!")(client)
+
+	if _, err := client.CreateMemoFromReader(context.Background(), strings.NewReader("content"), "notes.txt", nil); err == nil {
+		t.Fatal("expected error for invalid boundary")
+	}
+}
+
+func TestCreateMemoFromFile(t *testing.T) {
+	// Create a temporary test file
+	tmpFile, err := os.CreateTemp("", "test-*.pdf")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	// Write some test content
+	content := []byte("test PDF content")
+	if _, err := tmpFile.Write(content); err != nil {
+		t.Fatalf("failed to write to temp file: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		t.Fatalf("failed to close temp file: %v", err)
+	}
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" {
+			t.Errorf("expected POST request, got %s", req.Method)
 		}
 		if req.URL.Path != "/api/v1/memo" {
 			t.Errorf("expected path /api/v1/memo, got %s", req.URL.Path)
@@ -833,3 +1536,3722 @@ func TestCheckMemoStatusInvalidIDType(t *testing.T) {
 		t.Error("expected error for invalid idType")
 	}
 }
+
+func TestResolveMemoUUID(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "GET" {
+			t.Errorf("expected GET request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/memo/ext-123" {
+			t.Errorf("expected path /api/v1/memo/ext-123, got %s", req.URL.Path)
+		}
+		if req.URL.RawQuery != "id_type=reference_id" {
+			t.Errorf("expected params id_type=reference_id, got %s", req.URL.RawQuery)
+		}
+		return mockResponse(200, `{"uuid": "resolved-uuid", "client_reference_id": "ext-123"}`), nil
+	})
+
+	memoUUID, err := client.ResolveMemoUUID(context.Background(), "ext-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if memoUUID != "resolved-uuid" {
+		t.Errorf("expected UUID resolved-uuid, got %s", memoUUID)
+	}
+}
+
+func TestResolveMemoUUIDNotFound(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(404, `{"error": "Memo not found"}`), nil
+	})
+
+	memoUUID, err := client.ResolveMemoUUID(context.Background(), "missing-ref")
+	if err == nil {
+		t.Fatal("expected error for unknown reference ID")
+	}
+	if memoUUID != "" {
+		t.Errorf("expected empty UUID, got %s", memoUUID)
+	}
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if !apiErr.IsNotFound() {
+		t.Errorf("expected not found error, got status %d", apiErr.StatusCode)
+	}
+}
+
+func TestStreamedChatDoneEventWithUsage(t *testing.T) {
+	sseData := `data: {"type":"token","content":"Hello"}
+data: {"type":"done","chat_id":"chat-123","model":"gpt-4o","usage":{"prompt_tokens":120,"completion_tokens":30,"total_tokens":150}}
+`
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, sseData), nil
+	})
+
+	eventChan, errChan := client.StreamedChat(context.Background(), ChatParams{
+		Query: "test query",
+	})
+
+	var done ChatStreamEvent
+	for event := range eventChan {
+		if event.Type == "done" {
+			done = event
+		}
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if done.ChatID != "chat-123" {
+		t.Errorf("expected chat ID chat-123, got %s", done.ChatID)
+	}
+	if done.Model != "gpt-4o" {
+		t.Errorf("expected model gpt-4o, got %s", done.Model)
+	}
+	if done.Usage == nil {
+		t.Fatal("expected usage on done event")
+	}
+	if done.Usage.PromptTokens != 120 || done.Usage.CompletionTokens != 30 || done.Usage.TotalTokens != 150 {
+		t.Errorf("unexpected usage: %+v", *done.Usage)
+	}
+}
+
+func TestCollectChatStream(t *testing.T) {
+	sseData := `data: {"type":"token","content":"Paris "}
+data: {"type":"token","content":"[[1]]"}
+data: {"type":"references","references":{"1":{"memo_uuid":"uuid-1","memo_title":"Geography"}}}
+data: {"type":"done","chat_id":"chat-123","model":"gpt-4o","usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}
+`
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, sseData), nil
+	})
+
+	result, err := CollectChatStream(client.StreamedChat(context.Background(), ChatParams{
+		Query: "What is the capital of France?",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Response != "Paris [[1]]" {
+		t.Errorf("expected response %q, got %q", "Paris [[1]]", result.Response)
+	}
+	if result.ChatID != "chat-123" {
+		t.Errorf("expected chat ID chat-123, got %s", result.ChatID)
+	}
+	if result.Model != "gpt-4o" {
+		t.Errorf("expected model gpt-4o, got %s", result.Model)
+	}
+	if result.Usage == nil || result.Usage.TotalTokens != 15 {
+		t.Errorf("expected total tokens 15, got %+v", result.Usage)
+	}
+	if result.References["1"].MemoUUID != "uuid-1" {
+		t.Errorf("expected reference 1 to be uuid-1, got %+v", result.References)
+	}
+}
+
+func TestCollectChatStreamMergesReferences(t *testing.T) {
+	sseData := `data: {"type":"token","content":"Paris [[1]] is in France [[2]]"}
+data: {"type":"references","references":{"1":{"memo_uuid":"uuid-1","memo_title":"Geography"}}}
+data: {"type":"references","content":"{\"2\":{\"memo_uuid\":\"uuid-2\",\"memo_title\":\"Europe\"}}"}
+data: {"type":"done","chat_id":"chat-123"}
+`
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, sseData), nil
+	})
+
+	result, err := CollectChatStream(client.StreamedChat(context.Background(), ChatParams{
+		Query: "Where is Paris?",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.References) != 2 {
+		t.Fatalf("expected 2 references, got %+v", result.References)
+	}
+	if result.References["1"].MemoUUID != "uuid-1" {
+		t.Errorf("expected reference 1 to be uuid-1, got %+v", result.References["1"])
+	}
+	if result.References["2"].MemoUUID != "uuid-2" {
+		t.Errorf("expected reference 2 to be uuid-2, got %+v", result.References["2"])
+	}
+}
+
+func TestCollectChatStreamError(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(500, `{"error": "internal"}`), nil
+	})
+
+	_, err := CollectChatStream(client.StreamedChat(context.Background(), ChatParams{
+		Query: "test query",
+	}))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestChatUsage(t *testing.T) {
+	tests := []struct {
+		name          string
+		responseBody  string
+		expectedUsage *TokenUsage
+	}{
+		{
+			name: "with usage",
+			responseBody: `{
+				"ok": true,
+				"response": "Answer",
+				"intermediate_steps": [],
+				"usage": {"prompt_tokens": 200, "completion_tokens": 50, "total_tokens": 250}
+			}`,
+			expectedUsage: &TokenUsage{PromptTokens: 200, CompletionTokens: 50, TotalTokens: 250},
+		},
+		{
+			name: "without usage",
+			responseBody: `{
+				"ok": true,
+				"response": "Answer",
+				"intermediate_steps": []
+			}`,
+			expectedUsage: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				return mockResponse(200, tt.responseBody), nil
+			})
+
+			resp, err := client.Chat(context.Background(), ChatParams{Query: "test query"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.expectedUsage == nil {
+				if resp.Usage != nil {
+					t.Errorf("expected nil usage, got %+v", *resp.Usage)
+				}
+				return
+			}
+			if resp.Usage == nil {
+				t.Fatal("expected usage to be populated")
+			}
+			if *resp.Usage != *tt.expectedUsage {
+				t.Errorf("expected usage %+v, got %+v", *tt.expectedUsage, *resp.Usage)
+			}
+		})
+	}
+}
+
+func TestCreateMemoTimeoutWithExistingReferenceID(t *testing.T) {
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			// The server creates the memo but responds after the caller's deadline
+			time.Sleep(100 * time.Millisecond)
+			_, _ = io.WriteString(w, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`)
+			return
+		}
+		gets.Add(1)
+		if r.URL.Path != "/api/v1/memo/ext-123" || r.URL.Query().Get("id_type") != "reference_id" {
+			t.Errorf("expected lookup by reference_id, got %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		_, _ = io.WriteString(w, `{"uuid": "123e4567-e89b-12d3-a456-426614174000", "client_reference_id": "ext-123"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	refID := "ext-123"
+	resp, err := client.CreateMemo(ctx, MemoData{
+		Title:       "Test Memo",
+		Content:     "Test content",
+		ReferenceID: &refID,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.MemoUUID.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("expected existing memo UUID, got %s", resp.MemoUUID)
+	}
+	if gets.Load() != 1 {
+		t.Errorf("expected 1 reference lookup, got %d", gets.Load())
+	}
+}
+
+func TestCreateMemoTimeoutWithoutExistingMemo(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == "POST" {
+			return nil, errors.New("i/o timeout")
+		}
+		return mockResponse(404, `{"error": "Memo not found"}`), nil
+	})
+
+	refID := "ext-123"
+	_, err := client.CreateMemo(context.Background(), MemoData{
+		Title:       "Test Memo",
+		Content:     "Test content",
+		ReferenceID: &refID,
+	})
+	if err == nil {
+		t.Fatal("expected original create error")
+	}
+	if !strings.Contains(err.Error(), "i/o timeout") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestCreateMemoTimeoutWithoutReferenceID(t *testing.T) {
+	calls := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return nil, errors.New("i/o timeout")
+	})
+
+	_, err := client.CreateMemo(context.Background(), MemoData{
+		Title:   "Test Memo",
+		Content: "Test content",
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("expected no lookup without a reference ID, got %d requests", calls)
+	}
+}
+
+func TestTagFilterSemantics(t *testing.T) {
+	tags := []string{"security", "compliance"}
+
+	all := allTagsFilters(tags)
+	if len(all) != 2 {
+		t.Fatalf("expected one filter per tag for AND semantics, got %d", len(all))
+	}
+	for i, filter := range all {
+		values, ok := filter.Value.([]string)
+		if !ok || len(values) != 1 || values[0] != tags[i] {
+			t.Errorf("expected filter %d to match only tag %q, got %v", i, tags[i], filter.Value)
+		}
+		if filter.Field != "tags" || filter.Operator != FilterOperatorIn || filter.FilterType != FilterTypeNativeField {
+			t.Errorf("unexpected filter %+v", filter)
+		}
+	}
+
+	anyFilters := anyTagsFilters(tags)
+	if len(anyFilters) != 1 {
+		t.Fatalf("expected a single filter for OR semantics, got %d", len(anyFilters))
+	}
+	values, ok := anyFilters[0].Value.([]string)
+	if !ok || len(values) != 2 {
+		t.Errorf("expected filter to match any of %v, got %v", tags, anyFilters[0].Value)
+	}
+}
+
+func TestListMemosStatus(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if status := req.URL.Query().Get("status"); status != "processing" {
+			t.Errorf("expected status=processing, got status=%s", status)
+		}
+		return mockResponse(200, `{
+			"count": 2,
+			"results": [
+				{"uuid": "uuid-1", "status": "processing"},
+				{"uuid": "uuid-2"}
+			]
+		}`), nil
+	})
+
+	status := MemoStatusProcessing
+	resp, err := client.ListMemos(context.Background(), &ListMemosParams{Status: &status})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(resp.Results))
+	}
+	if resp.Results[0].Status != MemoStatusProcessing {
+		t.Errorf("expected status processing, got %q", resp.Results[0].Status)
+	}
+	if resp.Results[1].Status != "" {
+		t.Errorf("expected empty status when not reported, got %q", resp.Results[1].Status)
+	}
+}
+
+func TestListMemosNoStatus(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Has("status") {
+			t.Errorf("expected no status param, got %s", req.URL.RawQuery)
+		}
+		return mockResponse(200, `{"count": 0, "results": []}`), nil
+	})
+
+	if _, err := client.ListMemos(context.Background(), &ListMemosParams{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestListMemosFields(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if fields := req.URL.Query().Get("fields"); fields != "uuid,title" {
+			t.Errorf("expected fields=uuid,title, got fields=%s", fields)
+		}
+		return mockResponse(200, `{"count": 1, "results": [{"uuid": "uuid-1", "title": "Partial"}]}`), nil
+	})
+
+	resp, err := client.ListMemos(context.Background(), &ListMemosParams{Fields: []string{"uuid", "title"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	item := resp.Results[0]
+	if item.UUID != "uuid-1" || item.Title != "Partial" {
+		t.Errorf("unexpected selected fields %+v", item)
+	}
+	if item.Summary != "" || !item.CreatedAt.IsZero() {
+		t.Errorf("expected unselected fields to be zero, got %+v", item)
+	}
+}
+
+func TestGetMemoWithOptionsFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     GetMemoOptions
+		expected string
+	}{
+		{name: "selected", opts: GetMemoOptions{Fields: []string{"title", "summary"}}, expected: "title,summary"},
+		{name: "all fields", opts: GetMemoOptions{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				if req.URL.Query().Get("fields") != tt.expected {
+					t.Errorf("expected fields %q, got %q", tt.expected, req.URL.Query().Get("fields"))
+				}
+				if req.URL.Query().Get("id_type") != "reference_id" {
+					t.Errorf("expected id_type reference_id, got %q", req.URL.Query().Get("id_type"))
+				}
+				return mockResponse(200, `{"title": "Partial", "summary": "Short summary"}`), nil
+			})
+
+			memo, err := client.GetMemoWithOptions(context.Background(), "ref-1", tt.opts, IDTypeReferenceID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if memo.Title != "Partial" || memo.Summary != "Short summary" {
+				t.Errorf("unexpected selected fields %+v", memo)
+			}
+			if memo.UUID != "" || memo.Content != "" || memo.Metadata != nil {
+				t.Errorf("expected unselected fields to be zero, got %+v", memo)
+			}
+		})
+	}
+}
+
+func TestCountMatchingMemos(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "GET" || req.URL.Path != "/api/v1/memo" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+		query := req.URL.Query()
+		if query.Get("page_size") != "1" {
+			t.Errorf("expected page_size 1, got %q", query.Get("page_size"))
+		}
+		var filters []Filter
+		if err := json.Unmarshal([]byte(query.Get("filters")), &filters); err != nil {
+			t.Fatalf("failed to decode filters: %v", err)
+		}
+		if len(filters) != 1 || filters[0].Field != "department" || filters[0].Value != "engineering" {
+			t.Errorf("unexpected filters %+v", filters)
+		}
+		return mockResponse(200, `{"count": 42, "next": "https://api.useskald.com/api/v1/memo?page=2&page_size=1", "results": [{"uuid": "uuid-1"}]}`), nil
+	})
+
+	count, err := client.CountMatchingMemos(context.Background(), []Filter{
+		{Field: "department", Operator: FilterOperatorEq, Value: "engineering", FilterType: FilterTypeCustomMetadata},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 42 {
+		t.Errorf("expected 42 matching memos, got %d", count)
+	}
+}
+
+func TestStreamListMemos(t *testing.T) {
+	handled := make(chan string)
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("page_size") != "500" {
+			t.Errorf("expected page_size 500, got %q", req.URL.Query().Get("page_size"))
+		}
+
+		pr, pw := io.Pipe()
+		go func() {
+			_, _ = io.WriteString(pw, `{"count": 2, "next": null, "results": [{"uuid": "uuid-1", "title": "First"},`)
+			// The rest of the page is only sent once the first memo was handled,
+			// so this deadlocks unless items are decoded as they arrive
+			select {
+			case <-handled:
+			case <-time.After(5 * time.Second):
+				pw.CloseWithError(errors.New("first memo was not handled before the page was complete"))
+				return
+			}
+			_, _ = io.WriteString(pw, `{"uuid": "uuid-2", "title": "Second"}], "previous": null}`)
+			_ = pw.Close()
+		}()
+
+		return &http.Response{StatusCode: 200, Header: make(http.Header), Body: pr}, nil
+	})
+
+	var titles []string
+	pageSize := 500
+	err := client.StreamListMemos(context.Background(), &ListMemosParams{PageSize: &pageSize}, func(item MemoListItem) error {
+		titles = append(titles, item.Title)
+		if item.UUID == "uuid-1" {
+			handled <- item.UUID
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(titles, []string{"First", "Second"}) {
+		t.Errorf("expected titles [First Second], got %v", titles)
+	}
+}
+
+func TestStreamListMemosHandlerError(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, `{"count": 3, "results": [{"uuid": "uuid-1"}, {"uuid": "uuid-2"}, {"uuid": "uuid-3"}]}`), nil
+	})
+
+	errStop := errors.New("stop")
+	var handled []string
+	err := client.StreamListMemos(context.Background(), nil, func(item MemoListItem) error {
+		handled = append(handled, item.UUID)
+		if item.UUID == "uuid-2" {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	if !reflect.DeepEqual(handled, []string{"uuid-1", "uuid-2"}) {
+		t.Errorf("expected handling to stop after uuid-2, got %v", handled)
+	}
+}
+
+func TestStreamListMemosDecoding(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		strict        bool
+		expectedCount int
+		expectErr     bool
+	}{
+		{name: "null results", body: `{"count": 0, "results": null}`},
+		{name: "unknown field", body: `{"count": 1, "cursor": "abc", "results": [{"uuid": "uuid-1"}]}`, expectedCount: 1},
+		{name: "unknown field in strict mode", body: `{"count": 1, "cursor": "abc", "results": []}`, strict: true, expectErr: true},
+		{name: "unknown memo field in strict mode", body: `{"results": [{"uuid": "uuid-1", "extra": true}]}`, strict: true, expectErr: true},
+		{name: "malformed", body: `{"results": [{"uuid": "uuid-1"}`, expectedCount: 1, expectErr: true},
+		{name: "not an object", body: `[]`, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				return mockResponse(200, tt.body), nil
+			})
+			if tt.strict {
+				WithStrictDecoding()(client)
+			}
+
+			count := 0
+			err := client.StreamListMemos(context.Background(), nil, func(MemoListItem) error {
+				count++
+				return nil
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if count != tt.expectedCount {
+				t.Errorf("expected %d memos handled, got %d", tt.expectedCount, count)
+			}
+		})
+	}
+}
+
+func TestListMemosWithTags(t *testing.T) {
+	tests := []struct {
+		name            string
+		list            func(c *Client, params *ListMemosParams) (*ListMemosResponse, error)
+		expectedFilters string
+	}{
+		{
+			name: "all tags",
+			list: func(c *Client, params *ListMemosParams) (*ListMemosResponse, error) {
+				return c.ListMemosWithAllTags(context.Background(), []string{"a", "b"}, params)
+			},
+			expectedFilters: `[{"field":"tags","operator":"in","value":["a"],"filter_type":"native_field"},{"field":"tags","operator":"in","value":["b"],"filter_type":"native_field"}]`,
+		},
+		{
+			name: "any tags",
+			list: func(c *Client, params *ListMemosParams) (*ListMemosResponse, error) {
+				return c.ListMemosWithAnyTags(context.Background(), []string{"a", "b"}, params)
+			},
+			expectedFilters: `[{"field":"tags","operator":"in","value":["a","b"],"filter_type":"native_field"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				if req.URL.Path != "/api/v1/memo" {
+					t.Errorf("expected path /api/v1/memo, got %s", req.URL.Path)
+				}
+				if got := req.URL.Query().Get("filters"); got != tt.expectedFilters {
+					t.Errorf("expected filters %s, got %s", tt.expectedFilters, got)
+				}
+				if got := req.URL.Query().Get("page"); got != "3" {
+					t.Errorf("expected page 3, got %s", got)
+				}
+				return mockResponse(200, `{"count": 0, "next": null, "previous": null, "results": []}`), nil
+			})
+
+			page := 3
+			params := &ListMemosParams{Page: &page}
+			if _, err := tt.list(client, params); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(params.Filters) != 0 {
+				t.Error("expected caller params to be left unmodified")
+			}
+		})
+	}
+}
+
+func TestGetMemoContentServerRange(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/api/v1/memo/test-uuid/content" {
+			t.Errorf("expected path /api/v1/memo/test-uuid/content, got %s", req.URL.Path)
+		}
+		if req.URL.Query().Get("offset") != "10" || req.URL.Query().Get("length") != "5" {
+			t.Errorf("expected offset=10&length=5, got %s", req.URL.RawQuery)
+		}
+		return mockResponse(200, `{"content": "hello"}`), nil
+	})
+
+	content, err := client.GetMemoContent(context.Background(), "test-uuid", 10, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content != "hello" {
+		t.Errorf("expected content hello, got %q", content)
+	}
+}
+
+func TestGetMemoContentClientSideFallback(t *testing.T) {
+	var paths []string
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.Path)
+		if strings.HasSuffix(req.URL.Path, "/content") {
+			return mockResponse(404, `{"error": "Not found"}`), nil
+		}
+		if req.URL.Query().Get("id_type") != "reference_id" {
+			t.Errorf("expected fallback to keep id_type, got %s", req.URL.RawQuery)
+		}
+		return mockResponse(200, `{"uuid": "test-uuid", "content": "Grüße aus Berlin"}`), nil
+	})
+
+	content, err := client.GetMemoContent(context.Background(), "ref-1", 6, 3, IDTypeReferenceID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content != "aus" {
+		t.Errorf("expected content %q, got %q", "aus", content)
+	}
+	if len(paths) != 2 || paths[1] != "/api/v1/memo/ref-1" {
+		t.Errorf("expected range request followed by full fetch, got %v", paths)
+	}
+
+	// Ranges past the end of the content are truncated
+	content, err = client.GetMemoContent(context.Background(), "ref-1", 10, 100, IDTypeReferenceID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content != "Berlin" {
+		t.Errorf("expected content %q, got %q", "Berlin", content)
+	}
+}
+
+func TestGetMemoContentInvalidRange(t *testing.T) {
+	client := NewClient("test-key")
+	if _, err := client.GetMemoContent(context.Background(), "test-id", -1, 10); err == nil {
+		t.Error("expected error for negative offset")
+	}
+}
+
+func TestBatchSearch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+
+		var searchReq SearchRequest
+		if err := json.NewDecoder(req.Body).Decode(&searchReq); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+
+		// Earlier queries take longer so that completion order is reversed
+		switch searchReq.Query {
+		case "first":
+			time.Sleep(30 * time.Millisecond)
+		case "second":
+			time.Sleep(15 * time.Millisecond)
+		case "broken":
+			return mockResponse(500, `{"error": "internal"}`), nil
+		}
+		return mockResponse(200, `{"results": [{"memo_uuid": "`+searchReq.Query+`"}]}`), nil
+	})
+
+	responses, errs := client.BatchSearch(context.Background(), []SearchRequest{
+		{Query: "first"},
+		{Query: "second"},
+		{Query: "broken"},
+		{Query: "fourth"},
+	}, 2)
+
+	if len(responses) != 4 || len(errs) != 4 {
+		t.Fatalf("expected 4 responses and errors, got %d and %d", len(responses), len(errs))
+	}
+	for i, query := range []string{"first", "second", "", "fourth"} {
+		if query == "" {
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("unexpected error for query %d: %v", i, errs[i])
+			continue
+		}
+		if len(responses[i].Results) != 1 || responses[i].Results[0].MemoUUID != query {
+			t.Errorf("expected response %d to belong to query %q, got %+v", i, query, responses[i])
+		}
+	}
+	if errs[2] == nil {
+		t.Error("expected error for failing query")
+	}
+	if len(responses[2].Results) != 0 {
+		t.Error("expected empty response for failing query")
+	}
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent searches, got %d", maxInFlight)
+	}
+}
+
+func TestRawResponses(t *testing.T) {
+	memoBody := `{"uuid":"123e4567-e89b-12d3-a456-426614174000","title":"Test","future_field":{"nested":true}}`
+	listBody := `{"count":1,"next":null,"previous":null,"results":[{"uuid":"123e4567-e89b-12d3-a456-426614174000","future_field":1}]}`
+	searchBody := `{"results":[{"memo_uuid":"123e4567-e89b-12d3-a456-426614174000","rerank_score":0.9}]}`
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/api/v1/memo/ref-1":
+			if req.URL.Query().Get("id_type") != "reference_id" {
+				t.Errorf("expected id_type=reference_id, got %q", req.URL.Query().Get("id_type"))
+			}
+			return mockResponse(200, memoBody), nil
+		case req.Method == "GET" && req.URL.Path == "/api/v1/memo":
+			if req.URL.Query().Get("page_size") != "5" {
+				t.Errorf("expected page_size=5, got %q", req.URL.Query().Get("page_size"))
+			}
+			return mockResponse(200, listBody), nil
+		case req.Method == "POST" && req.URL.Path == "/api/v1/search":
+			return mockResponse(200, searchBody), nil
+		}
+		t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		return mockResponse(404, `{"error": "not found"}`), nil
+	})
+	WithStrictDecoding()(client)
+
+	memoRaw, err := client.GetMemoRaw(context.Background(), "ref-1", IDTypeReferenceID)
+	if err != nil {
+		t.Fatalf("GetMemoRaw: unexpected error: %v", err)
+	}
+	if string(memoRaw) != memoBody {
+		t.Errorf("GetMemoRaw: expected %s, got %s", memoBody, memoRaw)
+	}
+
+	pageSize := 5
+	listRaw, err := client.ListMemosRaw(context.Background(), &ListMemosParams{PageSize: &pageSize})
+	if err != nil {
+		t.Fatalf("ListMemosRaw: unexpected error: %v", err)
+	}
+	if string(listRaw) != listBody {
+		t.Errorf("ListMemosRaw: expected %s, got %s", listBody, listRaw)
+	}
+
+	searchRaw, err := client.SearchRaw(context.Background(), SearchRequest{Query: "test"})
+	if err != nil {
+		t.Fatalf("SearchRaw: unexpected error: %v", err)
+	}
+	if string(searchRaw) != searchBody {
+		t.Errorf("SearchRaw: expected %s, got %s", searchBody, searchRaw)
+	}
+
+	// Raw bodies can be decoded into caller-defined structs
+	var extended struct {
+		Memo
+		FutureField struct {
+			Nested bool `json:"nested"`
+		} `json:"future_field"`
+	}
+	if err := json.Unmarshal(memoRaw, &extended); err != nil {
+		t.Fatalf("failed to decode raw memo: %v", err)
+	}
+	if !extended.FutureField.Nested || extended.Title != "Test" {
+		t.Errorf("unexpected extended memo: %+v", extended)
+	}
+}
+
+func TestGetMemoRawError(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(404, `{"error": "not found"}`), nil
+	})
+
+	_, err := client.GetMemoRaw(context.Background(), "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsNotFound() {
+		t.Fatalf("expected not found APIError, got %v", err)
+	}
+
+	if _, err := client.GetMemoRaw(context.Background(), "id", IDType("invalid")); err == nil {
+		t.Error("expected error for invalid idType")
+	}
+}
+
+func TestSearchWithTimestampRangeFilter(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		if !strings.Contains(string(body), `"field":"created_at","operator":"gte","value":"2026-01-01T00:00:00Z"`) {
+			t.Errorf("expected RFC3339 created_at filter, got %s", body)
+		}
+		return mockResponse(200, `{"results": []}`), nil
+	})
+
+	_, err := client.Search(context.Background(), SearchRequest{
+		Query: "test",
+		Filters: []Filter{{
+			Field:      "created_at",
+			Operator:   FilterOperatorGte,
+			Value:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			FilterType: FilterTypeNativeField,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSearchTopN(t *testing.T) {
+	var requests []SearchRequest
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var searchReq SearchRequest
+		if err := json.NewDecoder(req.Body).Decode(&searchReq); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		requests = append(requests, searchReq)
+
+		// 5 results exist in total
+		var results []string
+		for i := *searchReq.Offset; i < min(*searchReq.Offset+*searchReq.Limit, 5); i++ {
+			results = append(results, fmt.Sprintf(`{"memo_uuid": "memo-%d", "chunk_uuid": "chunk-%d"}`, i, i))
+		}
+		return mockResponse(200, `{"results": [`+strings.Join(results, ",")+`]}`), nil
+	})
+
+	pageSize := 3
+	results, err := client.SearchTopN(context.Background(), SearchRequest{Query: "test", Limit: &pageSize}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	for i, r := range results {
+		if r.ChunkUUID != fmt.Sprintf("chunk-%d", i) {
+			t.Errorf("expected result %d to be chunk-%d, got %s", i, i, r.ChunkUUID)
+		}
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 searches, got %d", len(requests))
+	}
+	if *requests[0].Offset != 0 || *requests[0].Limit != 3 {
+		t.Errorf("expected first page offset 0 limit 3, got %d/%d", *requests[0].Offset, *requests[0].Limit)
+	}
+	if *requests[1].Offset != 3 || *requests[1].Limit != 3 {
+		t.Errorf("expected second page offset 3 limit 3, got %d/%d", *requests[1].Offset, *requests[1].Limit)
+	}
+}
+
+func TestSearchTopNStopsAtN(t *testing.T) {
+	var limits []int
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var searchReq SearchRequest
+		if err := json.NewDecoder(req.Body).Decode(&searchReq); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		limits = append(limits, *searchReq.Limit)
+
+		var results []string
+		for i := *searchReq.Offset; i < *searchReq.Offset+*searchReq.Limit; i++ {
+			results = append(results, fmt.Sprintf(`{"chunk_uuid": "chunk-%d"}`, i))
+		}
+		return mockResponse(200, `{"results": [`+strings.Join(results, ",")+`]}`), nil
+	})
+
+	results, err := client.SearchTopN(context.Background(), SearchRequest{Query: "test"}, 60)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 60 {
+		t.Errorf("expected 60 results, got %d", len(results))
+	}
+	if len(limits) != 2 || limits[0] != 50 || limits[1] != 10 {
+		t.Errorf("expected limits [50 10], got %v", limits)
+	}
+}
+
+// newPagedSearchClient returns a client whose searches page through total results
+func newPagedSearchClient(t *testing.T, total int) *Client {
+	return newMockClient(func(req *http.Request) (*http.Response, error) {
+		var searchReq SearchRequest
+		if err := json.NewDecoder(req.Body).Decode(&searchReq); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		var results []string
+		for i := *searchReq.Offset; i < min(*searchReq.Offset+*searchReq.Limit, total); i++ {
+			results = append(results, fmt.Sprintf(`{"chunk_uuid": "chunk-%d"}`, i))
+		}
+		return mockResponse(200, `{"results": [`+strings.Join(results, ",")+`]}`), nil
+	})
+}
+
+func TestSearchAll(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       int
+		maxResults  int
+		expectedLen int
+		truncated   bool
+	}{
+		{name: "fewer than cap", total: 30, maxResults: 100, expectedLen: 30},
+		{name: "exactly cap", total: 60, maxResults: 60, expectedLen: 60},
+		{name: "truncated at cap", total: 200, maxResults: 75, expectedLen: 75, truncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newPagedSearchClient(t, tt.total)
+
+			results, err := client.SearchAll(context.Background(), SearchRequest{Query: "test"}, tt.maxResults)
+			if tt.truncated {
+				if !errors.Is(err, ErrSearchTruncated) {
+					t.Errorf("expected ErrSearchTruncated, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(results) != tt.expectedLen {
+				t.Fatalf("expected %d results, got %d", tt.expectedLen, len(results))
+			}
+			for i, r := range results {
+				if r.ChunkUUID != fmt.Sprintf("chunk-%d", i) {
+					t.Errorf("expected result %d to be chunk-%d, got %s", i, i, r.ChunkUUID)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchAllInvalidMax(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		t.Error("expected no request")
+		return nil, nil
+	})
+
+	if _, err := client.SearchAll(context.Background(), SearchRequest{Query: "test"}, 0); err == nil {
+		t.Error("expected error for non-positive maxResults")
+	}
+}
+
+func TestSearchMemos(t *testing.T) {
+	var mu sync.Mutex
+	fetched := make(map[string]int)
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/api/v1/search" {
+			return mockResponse(200, `{"results": [
+				{"memo_uuid": "memo-b", "chunk_uuid": "chunk-1"},
+				{"memo_uuid": "memo-a", "chunk_uuid": "chunk-2"},
+				{"memo_uuid": "memo-b", "chunk_uuid": "chunk-3"},
+				{"memo_uuid": "memo-c", "chunk_uuid": "chunk-4"},
+				{"memo_uuid": "memo-a", "chunk_uuid": "chunk-5"}
+			]}`), nil
+		}
+
+		memoID := strings.TrimPrefix(req.URL.Path, "/api/v1/memo/")
+		mu.Lock()
+		fetched[memoID]++
+		mu.Unlock()
+		return mockResponse(200, fmt.Sprintf(`{"uuid": %q, "content": "Content of %s"}`, memoID, memoID)), nil
+	})
+
+	memos, err := client.SearchMemos(context.Background(), SearchRequest{Query: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var uuids []string
+	for _, memo := range memos {
+		uuids = append(uuids, memo.UUID)
+		if memo.Content != "Content of "+memo.UUID {
+			t.Errorf("expected full content of %s, got %q", memo.UUID, memo.Content)
+		}
+	}
+	if expected := []string{"memo-b", "memo-a", "memo-c"}; !reflect.DeepEqual(uuids, expected) {
+		t.Errorf("expected memos %v in result order, got %v", expected, uuids)
+	}
+	for memoID, count := range fetched {
+		if count != 1 {
+			t.Errorf("expected %s to be fetched once, got %d", memoID, count)
+		}
+	}
+}
+
+func TestSearchMemosFetchError(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/api/v1/search":
+			return mockResponse(200, `{"results": [{"memo_uuid": "memo-a"}, {"memo_uuid": "memo-b"}]}`), nil
+		case "/api/v1/memo/memo-b":
+			return mockResponse(404, `{"error": "Memo not found"}`), nil
+		default:
+			return mockResponse(200, `{"uuid": "memo-a"}`), nil
+		}
+	})
+
+	_, err := client.SearchMemos(context.Background(), SearchRequest{Query: "test"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsNotFound() {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestSearchTopNOffsetIgnored(t *testing.T) {
+	calls := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		// A server without offset support returns the same page every time
+		return mockResponse(200, `{"results": [{"chunk_uuid": "chunk-0"}, {"chunk_uuid": "chunk-1"}]}`), nil
+	})
+
+	pageSize := 2
+	results, err := client.SearchTopN(context.Background(), SearchRequest{Query: "test", Limit: &pageSize}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 unique results, got %d", len(results))
+	}
+	if calls != 2 {
+		t.Errorf("expected paging to stop after a page without new results, got %d calls", calls)
+	}
+}
+
+func TestSearchToNDJSONOffsetIgnoredWithoutChunkUUIDs(t *testing.T) {
+	calls := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls > 10 {
+			t.Fatal("expected paging to stop")
+		}
+		// A server without offset support returns the same page every time
+		return mockResponse(200, `{"results": [
+			{"memo_uuid": "memo-1", "content_snippet": "first"},
+			{"memo_uuid": "memo-1", "content_snippet": "second"}
+		]}`), nil
+	})
+
+	var buf strings.Builder
+	pageSize := 2
+	if err := client.SearchToNDJSON(context.Background(), SearchRequest{Query: "test", Limit: &pageSize}, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("expected 2 unique results, got %d", lines)
+	}
+	if calls != 2 {
+		t.Errorf("expected paging to stop after a page without new results, got %d calls", calls)
+	}
+}
+
+func TestSearchTopNMinContentLength(t *testing.T) {
+	var offsets []int
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var searchReq SearchRequest
+		if err := json.NewDecoder(req.Body).Decode(&searchReq); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		offsets = append(offsets, *searchReq.Offset)
+
+		// 50 results exist in total, every fifth of which is too short
+		var results []string
+		for i := *searchReq.Offset; i < min(*searchReq.Offset+*searchReq.Limit, 50); i++ {
+			snippet := "a long enough snippet"
+			if i%5 == 0 {
+				snippet = "short"
+			}
+			results = append(results, fmt.Sprintf(`{"chunk_uuid": "chunk-%d", "content_snippet": %q}`, i, snippet))
+		}
+		return mockResponse(200, `{"results": [`+strings.Join(results, ",")+`]}`), nil
+	})
+
+	pageSize := 10
+	minLength := 10
+	results, err := client.SearchTopN(context.Background(), SearchRequest{Query: "test", Limit: &pageSize, MinContentLength: &minLength}, 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 30 {
+		t.Fatalf("expected 30 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.ContentSnippet == "short" {
+			t.Errorf("expected short result %s to be dropped", r.ChunkUUID)
+		}
+	}
+	if len(offsets) < 2 || offsets[1] != 10 {
+		t.Errorf("expected the second page at the server offset 10, got offsets %v", offsets)
+	}
+}
+
+func TestSearchToNDJSON(t *testing.T) {
+	var offsets []string
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		offsets = append(offsets, fmt.Sprint(body["offset"]))
+
+		if body["offset"] == float64(0) {
+			return mockResponse(200, `{"results": [
+				{"memo_uuid": "memo-1", "chunk_uuid": "chunk-0", "content_snippet": "Q&A <b>first</b>"},
+				{"memo_uuid": "memo-1", "chunk_uuid": "chunk-1", "content_snippet": "second"}
+			]}`), nil
+		}
+		return mockResponse(200, `{"results": [
+			{"memo_uuid": "memo-2", "chunk_uuid": "chunk-2", "content_snippet": "third\nline"}
+		]}`), nil
+	})
+
+	var buf strings.Builder
+	pageSize := 2
+	if err := client.SearchToNDJSON(context.Background(), SearchRequest{Query: "test", Limit: &pageSize}, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(offsets, []string{"0", "2"}) {
+		t.Errorf("expected pages at offsets [0 2], got %v", offsets)
+	}
+
+	output := buf.String()
+	if !strings.HasSuffix(output, "\n") {
+		t.Error("expected output to end with a newline")
+	}
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), output)
+	}
+
+	var chunks []string
+	for _, line := range lines {
+		var result SearchResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		chunks = append(chunks, result.ChunkUUID)
+	}
+	if expected := []string{"chunk-0", "chunk-1", "chunk-2"}; !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected chunks %v, got %v", expected, chunks)
+	}
+	if !strings.Contains(lines[0], "Q&A <b>first</b>") {
+		t.Errorf("expected HTML not to be escaped, got %s", lines[0])
+	}
+}
+
+func TestSyncMemos(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		expectedFilters := `[{"field":"updated_at","operator":"gte","value":"2026-02-01T00:00:00Z","filter_type":"native_field"}]`
+		if got := req.URL.Query().Get("filters"); got != expectedFilters {
+			t.Errorf("expected filters %s, got %s", expectedFilters, got)
+		}
+		switch req.URL.Query().Get("page") {
+		case "1":
+			return mockResponse(200, `{"count": 4, "next": "https://api.useskald.com/api/v1/memo?page=2&page_size=100", "results": [
+				{"uuid": "old", "updated_at": "2026-01-01T00:00:00Z"},
+				{"uuid": "a", "updated_at": "2026-03-01T00:00:00Z"}
+			]}`), nil
+		case "2":
+			return mockResponse(200, `{"count": 4, "next": null, "results": [
+				{"uuid": "b", "updated_at": "2026-04-15T12:00:00Z"},
+				{"uuid": "c", "updated_at": "2026-02-10T00:00:00Z"}
+			]}`), nil
+		}
+		t.Errorf("unexpected page %q", req.URL.Query().Get("page"))
+		return mockResponse(404, `{"error": "not found"}`), nil
+	})
+
+	since := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	var handled []string
+	watermark, err := client.SyncMemos(context.Background(), since, func(item MemoListItem) error {
+		handled = append(handled, item.UUID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(handled, ",") != "a,b,c" {
+		t.Errorf("expected memos a,b,c to be handled, got %v", handled)
+	}
+	expected := time.Date(2026, 4, 15, 12, 0, 0, 0, time.UTC)
+	if !watermark.Equal(expected) {
+		t.Errorf("expected watermark %v, got %v", expected, watermark)
+	}
+}
+
+func TestSyncMemosHandlerError(t *testing.T) {
+	requests := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return mockResponse(200, `{"count": 3, "next": "https://api.useskald.com/api/v1/memo?page=2", "results": [
+			{"uuid": "a", "updated_at": "2026-03-01T00:00:00Z"},
+			{"uuid": "b", "updated_at": "2026-04-01T00:00:00Z"}
+		]}`), nil
+	})
+
+	since := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	handlerErr := errors.New("sink unavailable")
+	calls := 0
+	watermark, err := client.SyncMemos(context.Background(), since, func(item MemoListItem) error {
+		calls++
+		return handlerErr
+	})
+	if !errors.Is(err, handlerErr) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	if calls != 1 || requests != 1 {
+		t.Errorf("expected sync to stop after the first memo, got %d calls and %d requests", calls, requests)
+	}
+	if !watermark.Equal(since) {
+		t.Errorf("expected watermark to remain %v, got %v", since, watermark)
+	}
+}
+
+func TestSyncMemosNothingNew(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, `{"count": 1, "next": null, "results": [{"uuid": "old", "updated_at": "2026-01-01T00:00:00Z"}]}`), nil
+	})
+
+	since := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	watermark, err := client.SyncMemos(context.Background(), since, func(item MemoListItem) error {
+		t.Errorf("unexpected call for memo %s", item.UUID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !watermark.Equal(since) {
+		t.Errorf("expected watermark %v, got %v", since, watermark)
+	}
+}
+
+func TestSyncMemosFromStart(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if got := req.URL.Query().Get("filters"); got != "" {
+			t.Errorf("expected no filters for a full sync, got %s", got)
+		}
+		return mockResponse(200, `{"count": 1, "next": null, "results": [{"uuid": "a", "updated_at": "2026-01-01T00:00:00Z"}]}`), nil
+	})
+
+	var handled []string
+	watermark, err := client.SyncMemos(context.Background(), time.Time{}, func(item MemoListItem) error {
+		handled = append(handled, item.UUID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(handled) != 1 || !watermark.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected memo a to be handled, got %v with watermark %v", handled, watermark)
+	}
+}
+
+func TestStreamChats(t *testing.T) {
+	var inFlight, maxInFlight int32
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var chatReq chatRequest
+		if err := json.NewDecoder(req.Body).Decode(&chatReq); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		if chatReq.Query == "broken" {
+			return mockResponse(500, `{"error": "internal"}`), nil
+		}
+		return mockResponse(200, "data: {\"type\":\"token\",\"content\":\"answer to "+chatReq.Query+"\"}\n"+
+			"data: {\"type\":\"done\"}\n"), nil
+	})
+
+	queries := []string{"first", "second", "broken", "fourth"}
+	var chats []ChatParams
+	for _, query := range queries {
+		chats = append(chats, ChatParams{Query: query})
+	}
+
+	tokens := make(map[int]string)
+	done := make(map[int]bool)
+	errs := make(map[int]error)
+	for item := range client.StreamChats(context.Background(), chats, 2) {
+		if item.Err != nil {
+			errs[item.Index] = item.Err
+			continue
+		}
+		switch item.Event.Type {
+		case "token":
+			tokens[item.Index] += *item.Event.Content
+		case "done":
+			done[item.Index] = true
+		}
+	}
+
+	for i, query := range queries {
+		if query == "broken" {
+			continue
+		}
+		if tokens[i] != "answer to "+query {
+			t.Errorf("expected chat %d to receive its own answer, got %q", i, tokens[i])
+		}
+		if !done[i] {
+			t.Errorf("expected chat %d to complete", i)
+		}
+	}
+	if len(errs) != 1 || errs[2] == nil {
+		t.Errorf("expected a single error for chat 2, got %v", errs)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent chats, got %d", maxInFlight)
+	}
+}
+
+func TestPostRaw(t *testing.T) {
+	requestBody := `{"query":"test","experimental":{"mode":"deep"}}`
+	responseBody := `{"result":{"answer":42}}`
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" {
+			t.Errorf("expected POST request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/experimental/deep-search" {
+			t.Errorf("expected path /api/v1/experimental/deep-search, got %s", req.URL.Path)
+		}
+		if req.URL.Query().Get("beta") != "true" {
+			t.Errorf("expected beta=true query parameter, got %q", req.URL.RawQuery)
+		}
+		if req.Header.Get("Authorization") != "Bearer test-api-key" {
+			t.Errorf("expected Authorization header with Bearer token")
+		}
+		if req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected JSON content type, got %q", req.Header.Get("Content-Type"))
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		if string(body) != requestBody {
+			t.Errorf("expected body %s, got %s", requestBody, body)
+		}
+		return mockResponse(200, responseBody), nil
+	})
+
+	raw, err := client.PostRaw(context.Background(), "/api/v1/experimental/deep-search?beta=true", json.RawMessage(requestBody))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(raw) != responseBody {
+		t.Errorf("expected response %s, got %s", responseBody, raw)
+	}
+}
+
+func TestPostRawEmptyResponseAndErrors(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/api/v1/missing" {
+			return mockResponse(404, `{"error": "not found"}`), nil
+		}
+		return mockResponse(204, ""), nil
+	})
+
+	raw, err := client.PostRaw(context.Background(), "api/v1/fire-and-forget", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if raw != nil {
+		t.Errorf("expected nil response for empty body, got %s", raw)
+	}
+
+	_, err = client.PostRaw(context.Background(), "/api/v1/missing", json.RawMessage(`{}`))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsNotFound() {
+		t.Errorf("expected not found APIError, got %v", err)
+	}
+}
+
+func TestForEachConcurrentlyOrdering(t *testing.T) {
+	const n = 20
+	results := make([]int, n)
+
+	// Later indexes finish first, so completion order is the reverse of input order
+	var completed []int
+	var mu sync.Mutex
+	forEachConcurrently(n, n, func(i int) {
+		time.Sleep(time.Duration(n-i) * time.Millisecond)
+		mu.Lock()
+		completed = append(completed, i)
+		mu.Unlock()
+		results[i] = i * i
+	})
+
+	if len(completed) != n {
+		t.Fatalf("expected %d calls, got %d", n, len(completed))
+	}
+	for i, result := range results {
+		if result != i*i {
+			t.Errorf("expected result %d at index %d, got %d", i*i, i, result)
+		}
+	}
+}
+
+func TestBatchOutOfOrderResults(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, `{"results": [
+			{"index": 2, "memo_uuid": "third"},
+			{"index": 0, "memo_uuid": "first"},
+			{"index": 1, "status_code": 404, "error": "not found"}
+		]}`), nil
+	})
+
+	results, err := client.Batch(context.Background(), []BatchOp{
+		{Operation: BatchOperationCreate, Memo: &MemoData{Title: "First", Content: "1"}},
+		{Operation: BatchOperationDelete, MemoID: "missing"},
+		{Operation: BatchOperationCreate, Memo: &MemoData{Title: "Third", Content: "3"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if results[0].MemoUUID != "first" || results[0].Err != nil {
+		t.Errorf("unexpected result 0: %+v", results[0])
+	}
+	var apiErr *APIError
+	if !errors.As(results[1].Err, &apiErr) || !apiErr.IsNotFound() {
+		t.Errorf("expected not found error for result 1, got %+v", results[1])
+	}
+	if results[2].MemoUUID != "third" || results[2].Err != nil {
+		t.Errorf("unexpected result 2: %+v", results[2])
+	}
+}
+
+func TestBatchInvalidResultIndex(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, `{"results": [{"index": 0, "memo_uuid": "a"}, {"index": 0, "memo_uuid": "b"}]}`), nil
+	})
+
+	_, err := client.Batch(context.Background(), []BatchOp{
+		{Operation: BatchOperationDelete, MemoID: "a"},
+		{Operation: BatchOperationDelete, MemoID: "b"},
+	})
+	if err == nil {
+		t.Error("expected error for duplicate result index")
+	}
+}
+
+func TestSearchAndChat(t *testing.T) {
+	var chatReq chatRequest
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/api/v1/search":
+			var searchReq SearchRequest
+			if err := json.NewDecoder(req.Body).Decode(&searchReq); err != nil {
+				t.Errorf("failed to decode search request: %v", err)
+			}
+			if searchReq.Query != "pricing" {
+				t.Errorf("expected search query 'pricing', got %q", searchReq.Query)
+			}
+			if searchReq.Limit == nil || *searchReq.Limit != 3 {
+				t.Errorf("expected search limit 3, got %v", searchReq.Limit)
+			}
+			return mockResponse(200, `{"results": [
+				{"memo_uuid": "memo-1", "chunk_uuid": "chunk-1"},
+				{"memo_uuid": "memo-2", "chunk_uuid": "chunk-2"},
+				{"memo_uuid": "memo-1", "chunk_uuid": "chunk-3"}
+			]}`), nil
+		case "/api/v1/chat":
+			if err := json.NewDecoder(req.Body).Decode(&chatReq); err != nil {
+				t.Errorf("failed to decode chat request: %v", err)
+			}
+			return mockResponse(200, `{"ok": true, "response": "It costs $10 [[1]]"}`), nil
+		}
+		t.Errorf("unexpected path %s", req.URL.Path)
+		return mockResponse(404, `{"error": "not found"}`), nil
+	})
+
+	limit := 3
+	result, err := client.SearchAndChat(context.Background(), "pricing",
+		SearchRequest{Limit: &limit},
+		ChatParams{SystemPrompt: "Be brief", MemoUUIDs: []string{"ignored"}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Search.Results) != 3 {
+		t.Errorf("expected 3 search results, got %d", len(result.Search.Results))
+	}
+	expected := []string{"memo-1", "memo-2"}
+	if strings.Join(result.MemoUUIDs, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected memo UUIDs %v, got %v", expected, result.MemoUUIDs)
+	}
+	if strings.Join(chatReq.MemoUUIDs, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected chat scoped to %v, got %v", expected, chatReq.MemoUUIDs)
+	}
+	if chatReq.Query != "pricing" {
+		t.Errorf("expected chat query 'pricing', got %q", chatReq.Query)
+	}
+	if chatReq.SystemPrompt != "Be brief" {
+		t.Errorf("expected system prompt to be passed through, got %q", chatReq.SystemPrompt)
+	}
+	if result.Chat == nil || result.Chat.Response != "It costs $10 [[1]]" {
+		t.Errorf("unexpected chat response: %+v", result.Chat)
+	}
+}
+
+func TestSearchAndChatNoResults(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/api/v1/chat" {
+			t.Error("expected no chat request when the search has no results")
+		}
+		return mockResponse(200, `{"results": []}`), nil
+	})
+
+	result, err := client.SearchAndChat(context.Background(), "pricing", SearchRequest{}, ChatParams{})
+	if !errors.Is(err, ErrNoSearchResults) {
+		t.Fatalf("expected ErrNoSearchResults, got %v", err)
+	}
+	if result == nil || result.Search == nil {
+		t.Fatal("expected search response to be returned")
+	}
+	if result.Chat != nil {
+		t.Error("expected no chat response")
+	}
+}
+
+func TestCreateMemoFromFilePayloadTooLarge(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-*.pdf")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+	if err := tmpFile.Close(); err != nil {
+		t.Fatalf("failed to close temp file: %v", err)
+	}
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(413, ``), nil
+	})
+
+	_, err = client.CreateMemoFromFile(context.Background(), tmpFile.Name(), nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if !apiErr.IsPayloadTooLarge() {
+		t.Error("expected IsPayloadTooLarge to be true")
+	}
+	if !strings.Contains(apiErr.Message, "100MB") {
+		t.Errorf("expected message to mention the size limit, got %q", apiErr.Message)
+	}
+}
+
+func TestGetMemoETag(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		resp := mockResponse(200, `{"uuid": "test-uuid", "title": "Test Memo"}`)
+		resp.Header.Set("ETag", `"v3"`)
+		return resp, nil
+	})
+
+	memo, err := client.GetMemo(context.Background(), "test-uuid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if memo.ETag != `"v3"` {
+		t.Errorf("expected ETag %q, got %q", `"v3"`, memo.ETag)
+	}
+}
+
+func TestUpdateMemoIfMatch(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("If-Match") != `"v3"` {
+			t.Errorf("expected If-Match header %q, got %q", `"v3"`, req.Header.Get("If-Match"))
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		if strings.Contains(string(body), "v3") {
+			t.Error("expected version to be sent only as a header")
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	title := "Updated Title"
+	_, err := client.UpdateMemo(context.Background(), "test-uuid", UpdateMemoData{
+		Title:   &title,
+		IfMatch: `"v3"`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdateMemoConflict(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(409, `{"error": "Memo has been modified"}`), nil
+	})
+
+	title := "Updated Title"
+	_, err := client.UpdateMemo(context.Background(), "test-uuid", UpdateMemoData{
+		Title:   &title,
+		IfMatch: `"v2"`,
+	})
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if !apiErr.IsConflict() {
+		t.Errorf("expected IsConflict to be true, got status %d", apiErr.StatusCode)
+	}
+}
+
+func TestAppendMemoContent(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" || req.URL.Path != "/api/v1/memo/log-1/append" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+		if req.URL.Query().Get("id_type") != "reference_id" {
+			t.Errorf("expected id_type reference_id, got %q", req.URL.Query().Get("id_type"))
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if body["content"] != "\nnew entry" {
+			t.Errorf("expected appended content, got %v", body["content"])
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	result, err := client.AppendMemoContent(context.Background(), "log-1", "\nnew entry", IDTypeReferenceID)
+	if err != nil {
+		t.Fatalf("AppendMemoContent failed: %v", err)
+	}
+	if result.MemoUUID.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("unexpected memo UUID %s", result.MemoUUID)
+	}
+}
+
+func TestAppendMemoContentFallback(t *testing.T) {
+	var requests []string
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		switch req.Method {
+		case "POST":
+			return mockResponse(404, `{"error": "Not found"}`), nil
+		case "GET":
+			resp := mockResponse(200, `{"uuid": "123e4567-e89b-12d3-a456-426614174000", "content": "first entry"}`)
+			resp.Header.Set("ETag", `"v7"`)
+			return resp, nil
+		default:
+			if req.Header.Get("If-Match") != `"v7"` {
+				t.Errorf("expected If-Match header %q, got %q", `"v7"`, req.Header.Get("If-Match"))
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			if body["content"] != "first entry\nsecond entry" {
+				t.Errorf("expected concatenated content, got %v", body["content"])
+			}
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		}
+	})
+
+	if _, err := client.AppendMemoContent(context.Background(), "test-uuid", "\nsecond entry"); err != nil {
+		t.Fatalf("AppendMemoContent failed: %v", err)
+	}
+
+	expectedRequests := []string{
+		"POST /api/v1/memo/test-uuid/append",
+		"GET /api/v1/memo/test-uuid",
+		"PATCH /api/v1/memo/test-uuid",
+	}
+	if strings.Join(requests, ",") != strings.Join(expectedRequests, ",") {
+		t.Errorf("expected requests %v, got %v", expectedRequests, requests)
+	}
+}
+
+func TestAppendMemoContentFallbackConflict(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		switch req.Method {
+		case "POST":
+			return mockResponse(405, `{"error": "Method not allowed"}`), nil
+		case "GET":
+			resp := mockResponse(200, `{"uuid": "123e4567-e89b-12d3-a456-426614174000", "content": "first entry"}`)
+			resp.Header.Set("ETag", `"v7"`)
+			return resp, nil
+		default:
+			return mockResponse(409, `{"error": "Memo has been modified"}`), nil
+		}
+	})
+
+	_, err := client.AppendMemoContent(context.Background(), "test-uuid", "\nsecond entry")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsConflict() {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+}
+
+func TestAppendMemoContentFallbackWithoutETag(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		switch req.Method {
+		case "POST":
+			return mockResponse(404, `{"error": "Not found"}`), nil
+		case "GET":
+			return mockResponse(200, `{"uuid": "123e4567-e89b-12d3-a456-426614174000", "content": "first entry"}`), nil
+		default:
+			t.Error("expected no unversioned update to be sent")
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		}
+	})
+
+	if _, err := client.AppendMemoContent(context.Background(), "test-uuid", "\nsecond entry"); err == nil {
+		t.Fatal("expected error when the memo has no ETag")
+	}
+}
+
+func TestUpdateMemoWithoutIfMatch(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if _, ok := req.Header["If-Match"]; ok {
+			t.Error("expected no If-Match header")
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	title := "Updated Title"
+	if _, err := client.UpdateMemo(context.Background(), "test-uuid", UpdateMemoData{Title: &title}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParsePageFromURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		expectedPage int
+		expectedOK   bool
+	}{
+		{
+			name:         "next page URL",
+			raw:          "https://api.useskald.com/api/v1/memo?page=3&page_size=50",
+			expectedPage: 3,
+			expectedOK:   true,
+		},
+		{
+			name:         "relative URL",
+			raw:          "/api/v1/memo?page=2",
+			expectedPage: 2,
+			expectedOK:   true,
+		},
+		{
+			name:       "no page param",
+			raw:        "https://api.useskald.com/api/v1/memo?page_size=50",
+			expectedOK: false,
+		},
+		{
+			name:       "non-numeric page",
+			raw:        "https://api.useskald.com/api/v1/memo?page=last",
+			expectedOK: false,
+		},
+		{
+			name:       "malformed URL",
+			raw:        "http://[::1]:namedport?page=2",
+			expectedOK: false,
+		},
+		{
+			name:       "empty string",
+			raw:        "",
+			expectedOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, ok := ParsePageFromURL(tt.raw)
+			if ok != tt.expectedOK {
+				t.Fatalf("expected ok %v, got %v", tt.expectedOK, ok)
+			}
+			if page != tt.expectedPage {
+				t.Errorf("expected page %d, got %d", tt.expectedPage, page)
+			}
+		})
+	}
+}
+
+func TestGetMemoSummary(t *testing.T) {
+	tests := []struct {
+		name           string
+		memoID         string
+		idType         []IDType
+		expectedPath   string
+		expectedParams string
+	}{
+		{
+			name:           "by UUID",
+			memoID:         "test-uuid",
+			expectedPath:   "/api/v1/memo/test-uuid/summary",
+			expectedParams: "",
+		},
+		{
+			name:           "by reference ID",
+			memoID:         "test-ref-id",
+			idType:         []IDType{IDTypeReferenceID},
+			expectedPath:   "/api/v1/memo/test-ref-id/summary",
+			expectedParams: "id_type=reference_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				if req.Method != "GET" {
+					t.Errorf("expected GET request, got %s", req.Method)
+				}
+				if req.URL.Path != tt.expectedPath {
+					t.Errorf("expected path %s, got %s", tt.expectedPath, req.URL.Path)
+				}
+				if req.URL.RawQuery != tt.expectedParams {
+					t.Errorf("expected params %s, got %s", tt.expectedParams, req.URL.RawQuery)
+				}
+				if req.Body != nil && req.Body != http.NoBody {
+					t.Error("expected no request body")
+				}
+				return mockResponse(200, `{"summary": "A short summary"}`), nil
+			})
+
+			summary, err := client.GetMemoSummary(context.Background(), tt.memoID, tt.idType...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if summary != "A short summary" {
+				t.Errorf("expected summary %q, got %q", "A short summary", summary)
+			}
+		})
+	}
+}
+
+func TestGetMemoTags(t *testing.T) {
+	tests := []struct {
+		name           string
+		memoID         string
+		idType         []IDType
+		expectedPath   string
+		expectedParams string
+	}{
+		{
+			name:           "by UUID",
+			memoID:         "test-uuid",
+			expectedPath:   "/api/v1/memo/test-uuid/tags",
+			expectedParams: "",
+		},
+		{
+			name:           "by reference ID",
+			memoID:         "test-ref-id",
+			idType:         []IDType{IDTypeReferenceID},
+			expectedPath:   "/api/v1/memo/test-ref-id/tags",
+			expectedParams: "id_type=reference_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				if req.Method != "GET" {
+					t.Errorf("expected GET request, got %s", req.Method)
+				}
+				if req.URL.Path != tt.expectedPath {
+					t.Errorf("expected path %s, got %s", tt.expectedPath, req.URL.Path)
+				}
+				if req.URL.RawQuery != tt.expectedParams {
+					t.Errorf("expected params %s, got %s", tt.expectedParams, req.URL.RawQuery)
+				}
+				return mockResponse(200, `[{"uuid": "tag-1", "tag": "security"}, {"uuid": "tag-2", "tag": "compliance"}]`), nil
+			})
+
+			tags, err := client.GetMemoTags(context.Background(), tt.memoID, tt.idType...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := []MemoTag{{UUID: "tag-1", Tag: "security"}, {UUID: "tag-2", Tag: "compliance"}}
+			if len(tags) != len(expected) {
+				t.Fatalf("expected %d tags, got %d", len(expected), len(tags))
+			}
+			for i := range expected {
+				if tags[i] != expected[i] {
+					t.Errorf("expected tag %d to be %+v, got %+v", i, expected[i], tags[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetMemoTagsInvalidIDType(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		t.Error("expected no request")
+		return nil, nil
+	})
+
+	if _, err := client.GetMemoTags(context.Background(), "test-uuid", IDType("invalid")); err == nil {
+		t.Error("expected error for invalid idType")
+	}
+}
+
+func TestAddMemoTags(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" || req.URL.Path != "/api/v1/memo/test-uuid/tags" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+		var body addMemoTagsRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if !reflect.DeepEqual(body.Tags, []string{"archive", "2025"}) {
+			t.Errorf("expected tags [archive 2025], got %v", body.Tags)
+		}
+		return mockResponse(204, ``), nil
+	})
+
+	if err := client.AddMemoTags(context.Background(), "test-uuid", []string{"archive", "2025"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTagMemosMatching(t *testing.T) {
+	var mu sync.Mutex
+	var listedPages []string
+	tagged := make(map[string][]string)
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.Method == "GET" {
+			if !strings.Contains(req.URL.Query().Get("filters"), `"source"`) {
+				t.Errorf("expected filters to be sent, got %q", req.URL.Query().Get("filters"))
+			}
+			page := req.URL.Query().Get("page")
+			listedPages = append(listedPages, page)
+			if page == "1" {
+				return mockResponse(200, `{"count": 3, "next": "https://api.useskald.com/api/v1/memo?page=2&page_size=100", "results": [
+					{"uuid": "uuid-1"}, {"uuid": "uuid-2"}
+				]}`), nil
+			}
+			return mockResponse(200, `{"count": 3, "next": null, "results": [{"uuid": "uuid-3"}]}`), nil
+		}
+
+		var body addMemoTagsRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		memoID := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/api/v1/memo/"), "/tags")
+		tagged[memoID] = append(tagged[memoID], body.Tags...)
+		return mockResponse(204, ``), nil
+	})
+
+	filters := []Filter{{Field: "source", Operator: FilterOperatorEq, Value: "wiki", FilterType: FilterTypeNativeField}}
+	count, err := client.TagMemosMatching(context.Background(), filters, []string{"migrated"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 memos tagged, got %d", count)
+	}
+	if !reflect.DeepEqual(listedPages, []string{"1", "2"}) {
+		t.Errorf("expected pages [1 2] to be listed, got %v", listedPages)
+	}
+	expected := map[string][]string{
+		"uuid-1": {"migrated"},
+		"uuid-2": {"migrated"},
+		"uuid-3": {"migrated"},
+	}
+	if !reflect.DeepEqual(tagged, expected) {
+		t.Errorf("expected one tag call per memo %v, got %v", expected, tagged)
+	}
+}
+
+func TestTagMemosMatchingPartialFailure(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == "GET" {
+			return mockResponse(200, `{"count": 3, "next": null, "results": [
+				{"uuid": "uuid-1"}, {"uuid": "uuid-2"}, {"uuid": "uuid-3"}
+			]}`), nil
+		}
+		if req.URL.Path == "/api/v1/memo/uuid-2/tags" {
+			return mockResponse(404, `{"error": "Memo not found"}`), nil
+		}
+		return mockResponse(204, ``), nil
+	})
+
+	count, err := client.TagMemosMatching(context.Background(), nil, []string{"migrated"})
+	if count != 2 {
+		t.Errorf("expected 2 memos tagged, got %d", count)
+	}
+
+	var tagErr *TagMemosError
+	if !errors.As(err, &tagErr) {
+		t.Fatalf("expected TagMemosError, got %v", err)
+	}
+	if len(tagErr.Failed) != 1 || tagErr.Failed["uuid-2"] == nil {
+		t.Errorf("expected uuid-2 to have failed, got %v", tagErr.Failed)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsNotFound() {
+		t.Errorf("expected wrapped not found error, got %v", err)
+	}
+}
+
+func TestRegenerateSummary(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" {
+			t.Errorf("expected POST request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/memo/test-ref-id/summary/regenerate" {
+			t.Errorf("expected path /api/v1/memo/test-ref-id/summary/regenerate, got %s", req.URL.Path)
+		}
+		if req.URL.RawQuery != "id_type=reference_id" {
+			t.Errorf("expected params id_type=reference_id, got %s", req.URL.RawQuery)
+		}
+		return mockResponse(200, `{"summary": "A fresh summary"}`), nil
+	})
+
+	summary, err := client.RegenerateSummary(context.Background(), "test-ref-id", IDTypeReferenceID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary != "A fresh summary" {
+		t.Errorf("expected summary %q, got %q", "A fresh summary", summary)
+	}
+}
+
+func TestRegenerateSummaryAccepted(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(202, ``), nil
+	})
+
+	summary, err := client.RegenerateSummary(context.Background(), "test-uuid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary != "" {
+		t.Errorf("expected empty summary for asynchronous regeneration, got %q", summary)
+	}
+}
+
+func TestChatWithMemoUUIDs(t *testing.T) {
+	var requests []chatRequest
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var chatReq chatRequest
+		if err := json.NewDecoder(req.Body).Decode(&chatReq); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		requests = append(requests, chatReq)
+		if chatReq.Stream {
+			return mockResponse(200, "data: {\"type\":\"done\"}\n"), nil
+		}
+		return mockResponse(200, `{"ok": true, "response": "Answer"}`), nil
+	})
+
+	params := ChatParams{
+		Query:     "Summarize this document",
+		MemoUUIDs: []string{"uuid-1", "uuid-2"},
+	}
+	if _, err := client.Chat(context.Background(), params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := CollectChatStream(client.StreamedChat(context.Background(), params)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	for _, chatReq := range requests {
+		if len(chatReq.MemoUUIDs) != 2 || chatReq.MemoUUIDs[0] != "uuid-1" || chatReq.MemoUUIDs[1] != "uuid-2" {
+			t.Errorf("expected memo_uuids [uuid-1 uuid-2], got %v", chatReq.MemoUUIDs)
+		}
+	}
+}
+
+func TestSearchWithMemoUUIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		memoUUIDs   []string
+		expectField bool
+	}{
+		{name: "scoped", memoUUIDs: []string{"uuid-1", "uuid-2"}, expectField: true},
+		{name: "empty slice omitted", memoUUIDs: []string{}, expectField: false},
+		{name: "nil omitted", memoUUIDs: nil, expectField: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					t.Fatalf("failed to read request body: %v", err)
+				}
+				hasField := strings.Contains(string(body), `"memo_uuids"`)
+				if hasField != tt.expectField {
+					t.Errorf("expected memo_uuids present=%v, got body %s", tt.expectField, body)
+				}
+				if tt.expectField && !strings.Contains(string(body), `"memo_uuids":["uuid-1","uuid-2"]`) {
+					t.Errorf("expected memo_uuids to be serialized, got body %s", body)
+				}
+				return mockResponse(200, `{"results": []}`), nil
+			})
+
+			_, err := client.Search(context.Background(), SearchRequest{
+				Query:     "test query",
+				MemoUUIDs: tt.memoUUIDs,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// unknownSizeReader hides the concrete reader type so that the request size cannot be known upfront
+type unknownSizeReader struct {
+	r io.Reader
+}
+
+func (u *unknownSizeReader) Read(p []byte) (int, error) {
+	return u.r.Read(p)
+}
+
+func TestCreateMemoFromReaderChunked(t *testing.T) {
+	content := strings.Repeat("streamed document content ", 10000)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Length") != "" || r.ContentLength != -1 {
+			t.Errorf("expected no Content-Length, got header %q and length %d", r.Header.Get("Content-Length"), r.ContentLength)
+		}
+		if len(r.TransferEncoding) != 1 || r.TransferEncoding[0] != "chunked" {
+			t.Errorf("expected chunked transfer encoding, got %v", r.TransferEncoding)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-api-key" {
+			t.Errorf("expected Authorization header with Bearer token")
+		}
+
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse multipart form: %v", err)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("expected file field: %v", err)
+		}
+		defer func() { _ = file.Close() }()
+		uploaded, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatalf("failed to read uploaded file: %v", err)
+		}
+		if header.Filename != "notes.txt" {
+			t.Errorf("expected filename notes.txt, got %s", header.Filename)
+		}
+		if string(uploaded) != content {
+			t.Errorf("expected uploaded content of %d bytes, got %d bytes", len(content), len(uploaded))
+		}
+		if r.FormValue("title") != "Streamed" {
+			t.Errorf("expected title field Streamed, got %q", r.FormValue("title"))
+		}
+
+		_, _ = io.WriteString(w, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL)
+	title := "Streamed"
+	resp, err := client.CreateMemoFromReader(context.Background(), &unknownSizeReader{r: strings.NewReader(content)}, "notes.txt", &MemoFileData{
+		Title: &title,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.MemoUUID.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("unexpected MemoUUID %s", resp.MemoUUID)
+	}
+}
+
+func TestCheckMemoStatusProgress(t *testing.T) {
+	tests := []struct {
+		name             string
+		responseBody     string
+		expectedProgress *float64
+	}{
+		{
+			name:             "with progress",
+			responseBody:     `{"status": "processing", "progress": 42.5}`,
+			expectedProgress: func() *float64 { v := 42.5; return &v }(),
+		},
+		{
+			name:             "without progress",
+			responseBody:     `{"status": "processing"}`,
+			expectedProgress: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				return mockResponse(200, tt.responseBody), nil
+			})
+
+			status, err := client.CheckMemoStatus(context.Background(), "test-uuid")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectedProgress == nil {
+				if status.Progress != nil {
+					t.Errorf("expected nil progress, got %v", *status.Progress)
+				}
+				return
+			}
+			if status.Progress == nil || *status.Progress != *tt.expectedProgress {
+				t.Errorf("expected progress %v, got %v", *tt.expectedProgress, status.Progress)
+			}
+		})
+	}
+}
+
+func TestCheckMemoStatusTiming(t *testing.T) {
+	tests := []struct {
+		name             string
+		responseBody     string
+		expectedStarted  bool
+		expectedDuration time.Duration
+		expectedOK       bool
+	}{
+		{
+			name:             "with duration",
+			responseBody:     `{"status": "processed", "started_at": "2026-05-01T10:00:00Z", "completed_at": "2026-05-01T10:00:12Z", "duration_ms": 11850}`,
+			expectedStarted:  true,
+			expectedDuration: 11850 * time.Millisecond,
+			expectedOK:       true,
+		},
+		{
+			name:             "with timestamps only",
+			responseBody:     `{"status": "processed", "started_at": "2026-05-01T10:00:00Z", "completed_at": "2026-05-01T10:01:30Z"}`,
+			expectedStarted:  true,
+			expectedDuration: 90 * time.Second,
+			expectedOK:       true,
+		},
+		{
+			name:            "still processing",
+			responseBody:    `{"status": "processing", "started_at": "2026-05-01T10:00:00Z"}`,
+			expectedStarted: true,
+		},
+		{
+			name:         "without timing",
+			responseBody: `{"status": "processed"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				return mockResponse(200, tt.responseBody), nil
+			})
+
+			status, err := client.CheckMemoStatus(context.Background(), "test-uuid")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (status.StartedAt != nil) != tt.expectedStarted {
+				t.Errorf("expected StartedAt set to be %v, got %v", tt.expectedStarted, status.StartedAt)
+			}
+			if tt.expectedStarted && !status.StartedAt.Equal(time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)) {
+				t.Errorf("unexpected StartedAt %v", status.StartedAt)
+			}
+
+			duration, ok := status.ProcessingDuration()
+			if ok != tt.expectedOK || duration != tt.expectedDuration {
+				t.Errorf("expected duration %v (%v), got %v (%v)", tt.expectedDuration, tt.expectedOK, duration, ok)
+			}
+		})
+	}
+}
+
+func TestAPIErrorQuotaExceeded(t *testing.T) {
+	tests := []struct {
+		name              string
+		statusCode        int
+		body              string
+		expectedQuota     bool
+		expectedForbidden bool
+	}{
+		{
+			name:              "402 payment required",
+			statusCode:        402,
+			body:              `{"error": "Memo limit reached for your plan"}`,
+			expectedQuota:     true,
+			expectedForbidden: false,
+		},
+		{
+			name:              "403 with quota code",
+			statusCode:        403,
+			body:              `{"error": "Monthly chat quota exceeded", "code": "quota_exceeded"}`,
+			expectedQuota:     true,
+			expectedForbidden: true,
+		},
+		{
+			name:              "403 without quota code",
+			statusCode:        403,
+			body:              `{"error": "You do not have access to this project"}`,
+			expectedQuota:     false,
+			expectedForbidden: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				return mockResponse(tt.statusCode, tt.body), nil
+			})
+
+			_, err := client.Chat(context.Background(), ChatParams{Query: "test query"})
+			apiErr, ok := err.(*APIError)
+			if !ok {
+				t.Fatalf("expected *APIError, got %T", err)
+			}
+			if apiErr.IsQuotaExceeded() != tt.expectedQuota {
+				t.Errorf("expected IsQuotaExceeded %v, got %v", tt.expectedQuota, apiErr.IsQuotaExceeded())
+			}
+			if apiErr.IsForbidden() != tt.expectedForbidden {
+				t.Errorf("expected IsForbidden %v, got %v", tt.expectedForbidden, apiErr.IsForbidden())
+			}
+		})
+	}
+}
+
+func TestAPIErrorCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode string
+	}{
+		{
+			name:         "with code",
+			body:         `{"error": "Unknown filter operator", "code": "invalid_filter"}`,
+			expectedCode: "invalid_filter",
+		},
+		{
+			name:         "without code",
+			body:         `{"error": "Unknown filter operator"}`,
+			expectedCode: "",
+		},
+		{
+			name:         "non-JSON body",
+			body:         `Bad Request`,
+			expectedCode: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				return mockResponse(400, tt.body), nil
+			})
+
+			_, err := client.Search(context.Background(), SearchRequest{Query: "test query"})
+			apiErr, ok := err.(*APIError)
+			if !ok {
+				t.Fatalf("expected *APIError, got %T", err)
+			}
+			if apiErr.Code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, apiErr.Code)
+			}
+			if apiErr.Message != tt.body {
+				t.Errorf("expected message to be the response body, got %q", apiErr.Message)
+			}
+		})
+	}
+}
+
+func TestChatDisableRetrieval(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var chatReq chatRequest
+		if err := json.NewDecoder(req.Body).Decode(&chatReq); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if !chatReq.DisableRetrieval {
+			t.Error("expected disable_retrieval to be true")
+		}
+		if len(chatReq.Filters) != 0 || len(chatReq.MemoUUIDs) != 0 {
+			t.Errorf("expected retrieval scoping to be dropped, got filters %v and memo UUIDs %v", chatReq.Filters, chatReq.MemoUUIDs)
+		}
+		return mockResponse(200, `{"ok": true, "response": "Paris is the capital of France."}`), nil
+	})
+	WithDefaultFilters([]Filter{
+		{Field: "source", Operator: FilterOperatorEq, Value: "notion", FilterType: FilterTypeNativeField},
+	})(client)
+
+	resp, err := client.Chat(context.Background(), ChatParams{
+		Query:            "What is the capital of France?",
+		DisableRetrieval: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.References) != 0 {
+		t.Errorf("expected no references without retrieval, got %v", resp.References)
+	}
+	if len(resp.Footnotes()) != 0 {
+		t.Errorf("expected no footnotes without retrieval, got %v", resp.Footnotes())
+	}
+}
+
+func TestChatConflictingParams(t *testing.T) {
+	sourceFilter := Filter{Field: "source", Operator: FilterOperatorEq, Value: "notion", FilterType: FilterTypeNativeField}
+	referenceFilter := Filter{Field: "client_reference_id", Operator: FilterOperatorEq, Value: "doc-1", FilterType: FilterTypeNativeField}
+
+	tests := []struct {
+		name   string
+		params ChatParams
+	}{
+		{
+			name:   "filters without retrieval",
+			params: ChatParams{Query: "q", DisableRetrieval: true, Filters: []Filter{sourceFilter}},
+		},
+		{
+			name:   "memo UUIDs without retrieval",
+			params: ChatParams{Query: "q", DisableRetrieval: true, MemoUUIDs: []string{"uuid-1"}},
+		},
+		{
+			name:   "vector search without retrieval",
+			params: ChatParams{Query: "q", DisableRetrieval: true, RAGConfig: &RAGConfig{VectorSearch: &VectorSearchConfig{}}},
+		},
+		{
+			name:   "reranking without retrieval",
+			params: ChatParams{Query: "q", DisableRetrieval: true, RAGConfig: &RAGConfig{Reranking: &RerankingConfig{}}},
+		},
+		{
+			name:   "memo UUIDs with reference ID filter",
+			params: ChatParams{Query: "q", MemoUUIDs: []string{"uuid-1"}, Filters: []Filter{referenceFilter}},
+		},
+	}
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		t.Error("expected no request")
+		return nil, nil
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.Chat(context.Background(), tt.params); !errors.Is(err, ErrConflictingChatParams) {
+				t.Errorf("Chat: expected ErrConflictingChatParams, got %v", err)
+			}
+			if _, err := CollectChatStream(client.StreamedChat(context.Background(), tt.params)); !errors.Is(err, ErrConflictingChatParams) {
+				t.Errorf("StreamedChat: expected ErrConflictingChatParams, got %v", err)
+			}
+			if _, err := client.StreamRaw(context.Background(), tt.params); !errors.Is(err, ErrConflictingChatParams) {
+				t.Errorf("StreamRaw: expected ErrConflictingChatParams, got %v", err)
+			}
+		})
+	}
+}
+
+func TestChatParamsValidate(t *testing.T) {
+	valid := []ChatParams{
+		{Query: "q"},
+		{Query: "q", DisableRetrieval: true, RAGConfig: &RAGConfig{LLMProvider: LLMProviderOpenAI}},
+		{Query: "q", MemoUUIDs: []string{"uuid-1"}, Filters: []Filter{
+			{Field: "source", Operator: FilterOperatorEq, Value: "notion", FilterType: FilterTypeNativeField},
+		}},
+	}
+
+	for _, params := range valid {
+		if err := params.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", params, err)
+		}
+	}
+}
+
+func TestChatRetrievalEnabledByDefault(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		if strings.Contains(string(body), "disable_retrieval") {
+			t.Error("expected disable_retrieval to be omitted by default")
+		}
+		return mockResponse(200, `{"ok": true, "response": "Answer"}`), nil
+	})
+
+	if _, err := client.Chat(context.Background(), ChatParams{Query: "test query"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestChatLanguage(t *testing.T) {
+	french := "fr"
+	tests := []struct {
+		name     string
+		language *string
+	}{
+		{name: "set", language: &french},
+		{name: "unset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []map[string]interface{}
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				var body map[string]interface{}
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request body: %v", err)
+				}
+				bodies = append(bodies, body)
+				if body["stream"] == true {
+					return mockResponse(200, "data: {\"type\":\"done\"}\n"), nil
+				}
+				return mockResponse(200, `{"ok": true, "response": "Réponse"}`), nil
+			})
+
+			params := ChatParams{Query: "test query", Language: tt.language}
+			if _, err := client.Chat(context.Background(), params); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := CollectChatStream(client.StreamedChat(context.Background(), params)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, body := range bodies {
+				language, ok := body["language"]
+				if tt.language == nil {
+					if ok {
+						t.Errorf("expected language to be omitted, got %v", language)
+					}
+				} else if language != *tt.language {
+					t.Errorf("expected language %q, got %v", *tt.language, language)
+				}
+			}
+		})
+	}
+}
+
+func TestCancelMemoProcessing(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" {
+			t.Errorf("expected POST request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/memo/test-ref-id/cancel" {
+			t.Errorf("expected path /api/v1/memo/test-ref-id/cancel, got %s", req.URL.Path)
+		}
+		if req.URL.RawQuery != "id_type=reference_id" {
+			t.Errorf("expected params id_type=reference_id, got %s", req.URL.RawQuery)
+		}
+		return mockResponse(204, ``), nil
+	})
+
+	if err := client.CancelMemoProcessing(context.Background(), "test-ref-id", IDTypeReferenceID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCancelMemoProcessingAlreadyProcessed(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(409, `{"error": "Memo has already been processed"}`), nil
+	})
+
+	err := client.CancelMemoProcessing(context.Background(), "test-uuid")
+	if !errors.Is(err, ErrMemoAlreadyProcessed) {
+		t.Fatalf("expected ErrMemoAlreadyProcessed, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsConflict() {
+		t.Errorf("expected wrapped 409 APIError, got %v", err)
+	}
+}
+
+func TestWaitForMemoReady(t *testing.T) {
+	polls := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		polls++
+		if polls < 3 {
+			return mockResponse(200, `{"status": "processing"}`), nil
+		}
+		return mockResponse(200, `{"status": "processed"}`), nil
+	})
+
+	if err := client.WaitForMemoReady(context.Background(), "test-uuid", time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if polls != 3 {
+		t.Errorf("expected 3 polls, got %d", polls)
+	}
+}
+
+func TestWaitForMemoReadyUnknownStatus(t *testing.T) {
+	polls := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		polls++
+		switch polls {
+		case 1:
+			return mockResponse(200, `{"status": "pending"}`), nil
+		case 2:
+			return mockResponse(200, `{}`), nil
+		}
+		return mockResponse(200, `{"status": "processed"}`), nil
+	})
+
+	if err := client.WaitForMemoReady(context.Background(), "test-uuid", time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if polls != 3 {
+		t.Errorf("expected polling to continue through unknown statuses, got %d polls", polls)
+	}
+}
+
+func TestWaitForMemoReadyError(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, `{"status": "error", "error_reason": "File format not supported"}`), nil
+	})
+
+	err := client.WaitForMemoReady(context.Background(), "test-uuid", time.Millisecond)
+	if err == nil || err.Error() != "File format not supported" {
+		t.Errorf("expected processing error, got %v", err)
+	}
+}
+
+// mockMemoNotifier is a MemoNotifier returning a fixed status or error
+type mockMemoNotifier struct {
+	status *MemoStatusResponse
+	err    error
+	calls  []string
+}
+
+func (n *mockMemoNotifier) WaitForMemo(ctx context.Context, memoID string) (*MemoStatusResponse, error) {
+	n.calls = append(n.calls, memoID)
+	return n.status, n.err
+}
+
+func TestAwaitMemoProcessedPolling(t *testing.T) {
+	polls := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		polls++
+		if req.URL.Path != "/api/v1/memo/test-uuid/status" {
+			t.Errorf("expected path /api/v1/memo/test-uuid/status, got %s", req.URL.Path)
+		}
+		if polls < 2 {
+			return mockResponse(200, `{"status": "processing"}`), nil
+		}
+		return mockResponse(200, `{"status": "processed"}`), nil
+	})
+
+	if err := client.AwaitMemoProcessed(context.Background(), "test-uuid"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if polls != 2 {
+		t.Errorf("expected 2 polls, got %d", polls)
+	}
+}
+
+func TestAwaitMemoProcessedNotifier(t *testing.T) {
+	reason := "File format not supported"
+	tests := []struct {
+		name          string
+		notifier      *mockMemoNotifier
+		expectedError string
+		expectedPolls int
+	}{
+		{
+			name:     "processed",
+			notifier: &mockMemoNotifier{status: &MemoStatusResponse{Status: MemoStatusProcessed}},
+		},
+		{
+			name:          "failed",
+			notifier:      &mockMemoNotifier{status: &MemoStatusResponse{Status: MemoStatusError, ErrorReason: &reason}},
+			expectedError: reason,
+		},
+		{
+			name:          "notifier error",
+			notifier:      &mockMemoNotifier{err: errors.New("connection lost")},
+			expectedError: "connection lost",
+		},
+		{
+			name:          "unavailable falls back to polling",
+			notifier:      &mockMemoNotifier{err: ErrNotifierUnavailable},
+			expectedPolls: 1,
+		},
+		{
+			name:          "no status falls back to polling",
+			notifier:      &mockMemoNotifier{},
+			expectedPolls: 1,
+		},
+		{
+			name:          "empty status falls back to polling",
+			notifier:      &mockMemoNotifier{status: &MemoStatusResponse{}},
+			expectedPolls: 1,
+		},
+		{
+			name:          "processing status falls back to polling",
+			notifier:      &mockMemoNotifier{status: &MemoStatusResponse{Status: MemoStatusProcessing}},
+			expectedPolls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polls := 0
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				polls++
+				return mockResponse(200, `{"status": "processed"}`), nil
+			})
+			WithMemoNotifier(tt.notifier)(client)
+
+			err := client.AwaitMemoProcessed(context.Background(), "test-uuid")
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(tt.notifier.calls) != 1 || tt.notifier.calls[0] != "test-uuid" {
+				t.Errorf("expected notifier to be called for test-uuid, got %v", tt.notifier.calls)
+			}
+			if polls != tt.expectedPolls {
+				t.Errorf("expected %d polls, got %d", tt.expectedPolls, polls)
+			}
+		})
+	}
+}
+
+func TestAwaitMemoProcessedReferenceID(t *testing.T) {
+	polls := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		polls++
+		if req.URL.Path != "/api/v1/memo/ref-1/status" || req.URL.Query().Get("id_type") != "reference_id" {
+			t.Errorf("expected status poll by reference ID, got %s?%s", req.URL.Path, req.URL.RawQuery)
+		}
+		return mockResponse(200, `{"status": "processed"}`), nil
+	})
+	notifier := &mockMemoNotifier{status: &MemoStatusResponse{Status: MemoStatusProcessed}}
+	WithMemoNotifier(notifier)(client)
+
+	if err := client.AwaitMemoProcessed(context.Background(), "ref-1", IDTypeReferenceID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(notifier.calls) != 0 {
+		t.Errorf("expected the notifier not to be used for a reference ID, got %v", notifier.calls)
+	}
+	if polls != 1 {
+		t.Errorf("expected 1 poll, got %d", polls)
+	}
+}
+
+func TestBackoffInterval(t *testing.T) {
+	initial := 100 * time.Millisecond
+	maxInterval := time.Second
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for attempt, want := range expected {
+		if got := backoffInterval(initial, maxInterval, attempt); got != want {
+			t.Errorf("attempt %d: expected interval %v, got %v", attempt, want, got)
+		}
+	}
+}
+
+func TestWaitForMemoReadyWithBackoff(t *testing.T) {
+	var pollTimes []time.Time
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		pollTimes = append(pollTimes, time.Now())
+		if len(pollTimes) < 5 {
+			return mockResponse(200, `{"status": "processing"}`), nil
+		}
+		return mockResponse(200, `{"status": "processed"}`), nil
+	})
+
+	if err := client.WaitForMemoReadyWithBackoff(context.Background(), "test-uuid", 5*time.Millisecond, 20*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pollTimes) != 5 {
+		t.Fatalf("expected 5 polls, got %d", len(pollTimes))
+	}
+
+	// Waits are 5ms, 10ms, 20ms, 20ms; check the lower bounds only
+	minimums := []time.Duration{5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond}
+	for i, minimum := range minimums {
+		if gap := pollTimes[i+1].Sub(pollTimes[i]); gap < minimum {
+			t.Errorf("wait %d: expected at least %v, got %v", i, minimum, gap)
+		}
+	}
+}
+
+func TestHashContent(t *testing.T) {
+	// SHA-256 of "hello world"
+	expected := "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	if got := HashContent("hello world"); got != expected {
+		t.Errorf("expected hash %s, got %s", expected, got)
+	}
+	if HashContent("hello world") == HashContent("hello world!") {
+		t.Error("expected different content to hash differently")
+	}
+}
+
+func TestFindMemoByContentHash(t *testing.T) {
+	hash := HashContent("some content")
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		expectedFilters := `[{"field":"content_hash","operator":"eq","value":"` + hash + `","filter_type":"custom_metadata"}]`
+		if got := req.URL.Query().Get("filters"); got != expectedFilters {
+			t.Errorf("expected filters %s, got %s", expectedFilters, got)
+		}
+		return mockResponse(200, `{"count": 1, "results": [{"uuid": "existing-uuid"}]}`), nil
+	})
+
+	memo, err := client.FindMemoByContentHash(context.Background(), hash)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if memo == nil || memo.UUID != "existing-uuid" {
+		t.Errorf("expected existing-uuid, got %+v", memo)
+	}
+}
+
+func TestListMemoChunks(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"count": 3, "next": "https://api.useskald.com/api/v1/memo/test-uuid/chunks?page=2&page_size=2", "previous": null, "results": [
+			{"uuid": "chunk-0", "chunk_content": "First", "chunk_index": 0},
+			{"uuid": "chunk-1", "chunk_content": "Second", "chunk_index": 1}
+		]}`,
+		"2": `{"count": 3, "next": null, "previous": "https://api.useskald.com/api/v1/memo/test-uuid/chunks?page_size=2", "results": [
+			{"uuid": "chunk-2", "chunk_content": "Third", "chunk_index": 2}
+		]}`,
+	}
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/api/v1/memo/test-uuid/chunks" {
+			t.Errorf("expected path /api/v1/memo/test-uuid/chunks, got %s", req.URL.Path)
+		}
+		if req.URL.Query().Get("page_size") != "2" {
+			t.Errorf("expected page_size 2, got %s", req.URL.Query().Get("page_size"))
+		}
+		return mockResponse(200, pages[req.URL.Query().Get("page")]), nil
+	})
+
+	tests := []struct {
+		page          int
+		expectedUUIDs []string
+	}{
+		{page: 1, expectedUUIDs: []string{"chunk-0", "chunk-1"}},
+		{page: 2, expectedUUIDs: []string{"chunk-2"}},
+	}
+
+	for _, tt := range tests {
+		chunks, err := client.ListMemoChunks(context.Background(), "test-uuid", tt.page, 2)
+		if err != nil {
+			t.Fatalf("page %d: unexpected error: %v", tt.page, err)
+		}
+		if len(chunks) != len(tt.expectedUUIDs) {
+			t.Fatalf("page %d: expected %d chunks, got %d", tt.page, len(tt.expectedUUIDs), len(chunks))
+		}
+		for i, uuid := range tt.expectedUUIDs {
+			if chunks[i].UUID != uuid {
+				t.Errorf("page %d: expected chunk %d to be %s, got %s", tt.page, i, uuid, chunks[i].UUID)
+			}
+		}
+	}
+}
+
+func TestGetMemoChunkCount(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "GET" || req.URL.Path != "/api/v1/memo/doc-1/chunks" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+		query := req.URL.Query()
+		if query.Get("page") != "1" || query.Get("page_size") != "1" {
+			t.Errorf("expected a single-chunk page, got %s", req.URL.RawQuery)
+		}
+		if query.Get("id_type") != "reference_id" {
+			t.Errorf("expected id_type reference_id, got %q", query.Get("id_type"))
+		}
+		return mockResponse(200, `{"count": 137, "next": "https://api.useskald.com/api/v1/memo/doc-1/chunks?page=2&page_size=1", "previous": null, "results": [
+			{"uuid": "chunk-0", "chunk_content": "First", "chunk_index": 0}
+		]}`), nil
+	})
+
+	count, err := client.GetMemoChunkCount(context.Background(), "doc-1", IDTypeReferenceID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 137 {
+		t.Errorf("expected 137 chunks, got %d", count)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" || req.URL.Path != "/api/v1/memo/batch" {
+			t.Errorf("expected POST /api/v1/memo/batch, got %s %s", req.Method, req.URL.Path)
+		}
+		var batchReq batchRequest
+		if err := json.NewDecoder(req.Body).Decode(&batchReq); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if len(batchReq.Operations) != 3 || batchReq.Operations[0].Operation != BatchOperationCreate ||
+			batchReq.Operations[1].Operation != BatchOperationUpdate || batchReq.Operations[2].Operation != BatchOperationDelete {
+			t.Errorf("unexpected operations %+v", batchReq.Operations)
+		}
+		return mockResponse(200, `{"results": [
+			{"memo_uuid": "created-uuid"},
+			{"memo_uuid": "updated-uuid"},
+			{"status_code": 404, "error": "Memo not found"}
+		]}`), nil
+	})
+
+	title := "Updated"
+	results, err := client.Batch(context.Background(), []BatchOp{
+		{Operation: BatchOperationCreate, Memo: &MemoData{Title: "New", Content: "Content"}},
+		{Operation: BatchOperationUpdate, MemoID: "updated-uuid", Update: &UpdateMemoData{Title: &title}},
+		{Operation: BatchOperationDelete, MemoID: "missing-uuid"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].MemoUUID != "created-uuid" {
+		t.Errorf("unexpected create result %+v", results[0])
+	}
+	if results[1].Err != nil || results[1].MemoUUID != "updated-uuid" {
+		t.Errorf("unexpected update result %+v", results[1])
+	}
+	var apiErr *APIError
+	if !errors.As(results[2].Err, &apiErr) || !apiErr.IsNotFound() {
+		t.Errorf("expected not found error for delete, got %v", results[2].Err)
+	}
+}
+
+func TestBatchSequentialFallback(t *testing.T) {
+	var requests []string
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		switch {
+		case req.URL.Path == "/api/v1/memo/batch":
+			return mockResponse(404, `{"error": "Not found"}`), nil
+		case req.Method == "POST":
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		case req.Method == "PATCH":
+			return mockResponse(400, `{"error": "Invalid title"}`), nil
+		default:
+			return mockResponse(204, ``), nil
+		}
+	})
+
+	title := ""
+	results, err := client.Batch(context.Background(), []BatchOp{
+		{Operation: BatchOperationCreate, Memo: &MemoData{Title: "New", Content: "Content"}},
+		{Operation: BatchOperationUpdate, MemoID: "ref-1", IDType: IDTypeReferenceID, Update: &UpdateMemoData{Title: &title}},
+		{Operation: BatchOperationDelete, MemoID: "old-uuid"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedRequests := []string{
+		"POST /api/v1/memo/batch",
+		"POST /api/v1/memo",
+		"PATCH /api/v1/memo/ref-1",
+		"DELETE /api/v1/memo/old-uuid",
+	}
+	if strings.Join(requests, ",") != strings.Join(expectedRequests, ",") {
+		t.Errorf("expected requests %v, got %v", expectedRequests, requests)
+	}
+
+	if results[0].Err != nil || results[0].MemoUUID != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("unexpected create result %+v", results[0])
+	}
+	var apiErr *APIError
+	if !errors.As(results[1].Err, &apiErr) || !apiErr.IsBadRequest() {
+		t.Errorf("expected bad request error for update, got %v", results[1].Err)
+	}
+	if results[2].Err != nil {
+		t.Errorf("unexpected delete error %v", results[2].Err)
+	}
+}
+
+func TestBatchContentTransformer(t *testing.T) {
+	redact := func(content string) string {
+		return strings.ReplaceAll(content, "secret", "[redacted]")
+	}
+
+	t.Run("batch endpoint", func(t *testing.T) {
+		client := newMockClient(func(req *http.Request) (*http.Response, error) {
+			var batchReq batchRequest
+			if err := json.NewDecoder(req.Body).Decode(&batchReq); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			if got := batchReq.Operations[0].Memo.Content; got != "a [redacted] note" {
+				t.Errorf("expected create content to be transformed, got %q", got)
+			}
+			if got := *batchReq.Operations[1].Update.Content; got != "an updated [redacted]" {
+				t.Errorf("expected update content to be transformed, got %q", got)
+			}
+			return mockResponse(200, `{"results": [{"memo_uuid": "created-uuid"}, {"memo_uuid": "updated-uuid"}]}`), nil
+		})
+		WithContentTransformer(redact)(client)
+
+		memo := &MemoData{Title: "New", Content: "a secret note"}
+		content := "an updated secret"
+		if _, err := client.Batch(context.Background(), []BatchOp{
+			{Operation: BatchOperationCreate, Memo: memo},
+			{Operation: BatchOperationUpdate, MemoID: "updated-uuid", Update: &UpdateMemoData{Content: &content}},
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if memo.Content != "a secret note" || content != "an updated secret" {
+			t.Error("expected the caller's operations not to be modified")
+		}
+	})
+
+	t.Run("sequential fallback", func(t *testing.T) {
+		var contents []string
+		client := newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/api/v1/memo/batch" {
+				return mockResponse(404, `{"error": "Not found"}`), nil
+			}
+			var body struct {
+				Content string `json:"content"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			contents = append(contents, body.Content)
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		})
+		WithContentTransformer(redact)(client)
+
+		content := "an updated secret"
+		if _, err := client.Batch(context.Background(), []BatchOp{
+			{Operation: BatchOperationCreate, Memo: &MemoData{Title: "New", Content: "a secret note"}},
+			{Operation: BatchOperationUpdate, MemoID: "updated-uuid", Update: &UpdateMemoData{Content: &content}},
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected := []string{"a [redacted] note", "an updated [redacted]"}; !reflect.DeepEqual(contents, expected) {
+			t.Errorf("expected contents %v to be transformed once, got %v", expected, contents)
+		}
+	})
+}
+
+func TestBatchMetadataSchema(t *testing.T) {
+	calls := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return mockResponse(200, `{"results": [{"memo_uuid": "created-uuid"}, {"memo_uuid": "updated-uuid"}]}`), nil
+	})
+	WithMetadataSchema(map[string]reflect.Kind{"priority": reflect.Int})(client)
+
+	_, err := client.Batch(context.Background(), []BatchOp{
+		{Operation: BatchOperationCreate, Memo: &MemoData{Title: "New", Content: "Content", Metadata: map[string]interface{}{"priority": 1}}},
+		{Operation: BatchOperationUpdate, MemoID: "updated-uuid", Update: &UpdateMemoData{Metadata: map[string]interface{}{"priority": "high"}}},
+	})
+	if !errors.Is(err, ErrInvalidMetadata) {
+		t.Fatalf("expected ErrInvalidMetadata, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "batch operation 1") {
+		t.Errorf("expected the error to name the operation, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected no request to be sent, got %d", calls)
+	}
+}
+
+func TestStreamedChatWithCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"type\":\"token\",\"content\":\"Hello\"}\n\n")
+		w.(http.Flusher).Flush()
+
+		// Keep the stream open until the client goes away
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL)
+	eventChan, errChan, cancel := client.StreamedChatWithCancel(context.Background(), ChatParams{
+		Query: "test query",
+	})
+
+	event := <-eventChan
+	if event.Type != "token" || event.Content == nil || *event.Content != "Hello" {
+		t.Fatalf("unexpected first event %+v", event)
+	}
+
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range eventChan {
+		}
+		if err := <-errChan; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected channels to be closed after cancel")
+	}
+}
+
+func TestStreamedChatCancelWithoutReading(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, "data: {\"type\":\"token\",\"content\":\"Hello\"}\ndata: {\"type\":\"done\"}\n"), nil
+	})
+
+	eventChan, errChan := client.StreamedChat(ctx, ChatParams{Query: "test query"})
+
+	// The stream goroutine must not block on sending events nobody reads
+	cancel()
+	select {
+	case <-errChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected stream goroutine to exit after cancel")
+	}
+	for range eventChan {
+	}
+}
+
+func TestCreateMemoFromURL(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" || req.URL.Path != "/api/v1/memo/from-url" {
+			t.Errorf("expected POST /api/v1/memo/from-url, got %s %s", req.Method, req.URL.Path)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if body["url"] != "https://example.com/docs/report.pdf" {
+			t.Errorf("expected url in request body, got %v", body["url"])
+		}
+		if body["title"] != "Report" {
+			t.Errorf("expected title in request body, got %v", body["title"])
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+
+	title := "Report"
+	resp, err := client.CreateMemoFromURL(context.Background(), "https://example.com/docs/report.pdf", &MemoFileData{Title: &title})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.MemoUUID.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("unexpected MemoUUID %s", resp.MemoUUID)
+	}
+}
+
+func TestCreateMemoFromURLDownloadFallback(t *testing.T) {
+	fileServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("expected download request not to carry the API key")
+		}
+		_, _ = io.WriteString(w, "downloaded PDF content")
+	}))
+	defer fileServer.Close()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/memo/from-url" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path != "/api/v1/memo" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("expected file field: %v", err)
+		}
+		defer func() { _ = file.Close() }()
+		content, _ := io.ReadAll(file)
+		if string(content) != "downloaded PDF content" {
+			t.Errorf("expected downloaded content to be uploaded, got %q", content)
+		}
+		if header.Filename != "report.pdf" {
+			t.Errorf("expected filename report.pdf, got %s", header.Filename)
+		}
+		_, _ = io.WriteString(w, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`)
+	}))
+	defer apiServer.Close()
+
+	client := NewClient("test-api-key", apiServer.URL)
+	resp, err := client.CreateMemoFromURL(context.Background(), fileServer.URL+"/docs/report.pdf", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.MemoUUID.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("unexpected MemoUUID %s", resp.MemoUUID)
+	}
+}
+
+func TestCreateMemoFromURLDownloadTooLarge(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/api/v1/memo/from-url" {
+			return mockResponse(404, `{"error": "Not found"}`), nil
+		}
+		resp := mockResponse(200, "")
+		resp.ContentLength = 101 * 1024 * 1024
+		return resp, nil
+	})
+
+	_, err := client.CreateMemoFromURL(context.Background(), "https://example.com/huge.pdf", nil)
+	if err == nil || !strings.Contains(err.Error(), "100MB") {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+}
+
+func TestDescribeMetadataFields(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "GET" {
+			t.Errorf("expected GET request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/memo/metadata-fields" {
+			t.Errorf("expected path /api/v1/memo/metadata-fields, got %s", req.URL.Path)
+		}
+		return mockResponse(200, `{"fields": [{"key": "author", "type": "string", "memo_count": 12}, {"key": "year", "type": "number", "memo_count": 3}]}`), nil
+	})
+
+	fields, err := client.DescribeMetadataFields(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []MetadataField{
+		{Key: "author", Type: "string", MemoCount: 12},
+		{Key: "year", Type: "number", MemoCount: 3},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expected fields %+v, got %+v", expected, fields)
+	}
+}
+
+func TestDescribeMetadataFieldsError(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(500, `{"error": "internal"}`), nil
+	})
+
+	if _, err := client.DescribeMetadataFields(context.Background()); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// countingDialer counts the connections opened by a transport
+type countingDialer struct {
+	dials atomic.Int32
+}
+
+func (d *countingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.dials.Add(1)
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, addr)
+}
+
+func TestConnectionReuse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/api/v1/memo/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error": "not found"}`+"\n")
+		case r.URL.Path == "/api/v1/search":
+			_, _ = io.WriteString(w, `{"results": []}`+"\n")
+		case r.URL.Path == "/api/v1/memo":
+			_, _ = io.WriteString(w, `{"count": 0, "results": []}`+"\n")
+		default:
+			// Trailing whitespace is left unread by the JSON decoder
+			_, _ = io.WriteString(w, `{"uuid": "test-uuid"}`+strings.Repeat(" ", 32<<10))
+		}
+	}))
+	defer server.Close()
+
+	dialer := &countingDialer{}
+	client := NewClient("test-api-key")
+	WithBaseURL(server.URL)(client)
+	WithHTTPClient(&http.Client{Transport: &http.Transport{DialContext: dialer.DialContext}})(client)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetMemo(ctx, "test-uuid"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.GetMemo(ctx, "missing"); err == nil {
+			t.Fatal("expected error for missing memo")
+		}
+		if _, err := client.ListMemos(ctx, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.Search(ctx, SearchRequest{Query: "test"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := client.DeleteMemo(ctx, "test-uuid"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if dials := dialer.dials.Load(); dials != 1 {
+		t.Errorf("expected sequential requests to reuse 1 connection, got %d", dials)
+	}
+}
+
+func TestStreamRaw(t *testing.T) {
+	stream := ": ping\n\ndata: {\"type\":\"token\",\"content\":\"Hello\"}\n\ndata: {\"type\":\"done\"}\n\n"
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" {
+			t.Errorf("expected POST request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/chat" {
+			t.Errorf("expected path /api/v1/chat, got %s", req.URL.Path)
+		}
+		if req.Header.Get("Authorization") != "Bearer test-api-key" {
+			t.Errorf("expected Authorization header, got %s", req.Header.Get("Authorization"))
+		}
+
+		var chatReq chatRequest
+		if err := json.NewDecoder(req.Body).Decode(&chatReq); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if !chatReq.Stream {
+			t.Error("expected stream to be true")
+		}
+		return mockResponse(200, stream), nil
+	})
+
+	body, err := client.StreamRaw(context.Background(), ChatParams{Query: "test query"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+	if string(raw) != stream {
+		t.Errorf("expected raw stream %q, got %q", stream, string(raw))
+	}
+}
+
+func TestStreamRawError(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(401, `{"error": "unauthorized"}`), nil
+	})
+
+	body, err := client.StreamRaw(context.Background(), ChatParams{Query: "test query"})
+	if body != nil {
+		t.Error("expected no body on error")
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsUnauthorized() {
+		t.Errorf("expected unauthorized APIError, got %v", err)
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "GET" {
+			t.Errorf("expected GET request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/usage" {
+			t.Errorf("expected path /api/v1/usage, got %s", req.URL.Path)
+		}
+		return mockResponse(200, `{
+			"plan": "pro",
+			"memo_count": 1250,
+			"memo_limit": 10000,
+			"storage_bytes": 524288000,
+			"storage_limit_bytes": null,
+			"requests": {
+				"used": 4200,
+				"limit": 50000,
+				"resets_at": "2026-11-01T00:00:00Z"
+			}
+		}`), nil
+	})
+
+	usage, err := client.GetUsage(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usage.Plan != "pro" {
+		t.Errorf("expected plan pro, got %s", usage.Plan)
+	}
+	if usage.MemoCount != 1250 || usage.MemoLimit == nil || *usage.MemoLimit != 10000 {
+		t.Errorf("expected 1250 of 10000 memos, got %d of %v", usage.MemoCount, usage.MemoLimit)
+	}
+	if usage.StorageBytes != 524288000 {
+		t.Errorf("expected 524288000 storage bytes, got %d", usage.StorageBytes)
+	}
+	if usage.StorageLimitBytes != nil {
+		t.Errorf("expected no storage limit, got %d", *usage.StorageLimitBytes)
+	}
+	if usage.Requests.Used != 4200 || usage.Requests.Limit == nil || *usage.Requests.Limit != 50000 {
+		t.Errorf("expected 4200 of 50000 requests, got %d of %v", usage.Requests.Used, usage.Requests.Limit)
+	}
+	expectedReset := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	if usage.Requests.ResetsAt == nil || !usage.Requests.ResetsAt.Equal(expectedReset) {
+		t.Errorf("expected quota reset at %v, got %v", expectedReset, usage.Requests.ResetsAt)
+	}
+}
+
+func TestServerInfo(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "GET" {
+			t.Errorf("expected GET request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/version" {
+			t.Errorf("expected path /api/version, got %s", req.URL.Path)
+		}
+		return mockResponse(200, `{"version": "2.4.1", "features": ["cursor_pagination", "webhooks"]}`), nil
+	})
+
+	info, err := client.ServerInfo(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Version != "2.4.1" {
+		t.Errorf("expected version 2.4.1, got %s", info.Version)
+	}
+	if !info.Supports("cursor_pagination") || !info.Supports("webhooks") {
+		t.Errorf("expected advertised features to be supported, got %v", info.Features)
+	}
+	if info.Supports("batch") {
+		t.Error("expected unadvertised feature not to be supported")
+	}
+}
+
+func TestRegisterWebhook(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" {
+			t.Errorf("expected POST request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/webhooks" {
+			t.Errorf("expected path /api/v1/webhooks, got %s", req.URL.Path)
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		expected := `{"url":"https://example.com/hooks/skald","events":["memo.processed","memo.error"]}`
+		if string(body) != expected {
+			t.Errorf("expected body %s, got %s", expected, body)
+		}
+		return mockResponse(201, `{
+			"uuid": "webhook-uuid",
+			"url": "https://example.com/hooks/skald",
+			"events": ["memo.processed", "memo.error"],
+			"created_at": "2026-10-01T12:00:00Z",
+			"secret": "whsec_123"
+		}`), nil
+	})
+
+	webhook, err := client.RegisterWebhook(context.Background(), "https://example.com/hooks/skald",
+		[]string{WebhookEventMemoProcessed, WebhookEventMemoError})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if webhook.UUID != "webhook-uuid" || webhook.Secret != "whsec_123" {
+		t.Errorf("unexpected webhook %+v", webhook)
+	}
+	if len(webhook.Events) != 2 || webhook.Events[0] != WebhookEventMemoProcessed {
+		t.Errorf("expected events [memo.processed memo.error], got %v", webhook.Events)
+	}
+}
+
+func TestRegisterWebhookValidation(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		t.Error("expected no request")
+		return nil, nil
+	})
+
+	if _, err := client.RegisterWebhook(context.Background(), "", []string{WebhookEventMemoProcessed}); err == nil {
+		t.Error("expected error for empty URL")
+	}
+	if _, err := client.RegisterWebhook(context.Background(), "https://example.com/hooks/skald", nil); err == nil {
+		t.Error("expected error for no events")
+	}
+}
+
+func TestListWebhooks(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "GET" {
+			t.Errorf("expected GET request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/webhooks" {
+			t.Errorf("expected path /api/v1/webhooks, got %s", req.URL.Path)
+		}
+		return mockResponse(200, `[
+			{"uuid": "webhook-1", "url": "https://example.com/a", "events": ["memo.processed"], "created_at": "2026-10-01T12:00:00Z"},
+			{"uuid": "webhook-2", "url": "https://example.com/b", "events": ["memo.error"], "created_at": "2026-10-02T12:00:00Z"}
+		]`), nil
+	})
+
+	webhooks, err := client.ListWebhooks(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(webhooks) != 2 {
+		t.Fatalf("expected 2 webhooks, got %d", len(webhooks))
+	}
+	if webhooks[1].UUID != "webhook-2" || webhooks[1].URL != "https://example.com/b" || webhooks[1].Events[0] != WebhookEventMemoError {
+		t.Errorf("unexpected webhook %+v", webhooks[1])
+	}
+	if webhooks[0].Secret != "" {
+		t.Errorf("expected no secret in list, got %q", webhooks[0].Secret)
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "DELETE" {
+			t.Errorf("expected DELETE request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/webhooks/webhook-uuid" {
+			t.Errorf("expected path /api/v1/webhooks/webhook-uuid, got %s", req.URL.Path)
+		}
+		return mockResponse(204, ``), nil
+	})
+
+	if err := client.DeleteWebhook(context.Background(), "webhook-uuid"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStreamMemoStatus(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "GET" {
+			t.Errorf("expected GET request, got %s", req.Method)
+		}
+		if req.URL.Path != "/api/v1/memo/test-ref-id/status/stream" {
+			t.Errorf("expected path /api/v1/memo/test-ref-id/status/stream, got %s", req.URL.Path)
+		}
+		if req.URL.RawQuery != "id_type=reference_id" {
+			t.Errorf("expected params id_type=reference_id, got %s", req.URL.RawQuery)
+		}
+		return mockResponse(200, "data: {\"status\":\"processing\"}\n\n"+
+			": ping\n\n"+
+			"data: not json\n\n"+
+			"data: {\"status\":\"processing\"}\n\n"+
+			"data: {\"status\":\"processed\"}\n\n"+
+			"data: {\"status\":\"processing\"}\n\n"), nil
+	})
+
+	statusChan, errChan := client.StreamMemoStatus(context.Background(), "test-ref-id", IDTypeReferenceID)
+	var statuses []MemoStatus
+	for status := range statusChan {
+		statuses = append(statuses, status.Status)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The stream ends at the terminal status
+	expected := []MemoStatus{MemoStatusProcessing, MemoStatusProcessing, MemoStatusProcessed}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("expected statuses %v, got %v", expected, statuses)
+	}
+}
+
+func TestStreamMemoStatusError(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(200, "data: {\"status\":\"error\",\"error_reason\":\"File format not supported\"}\n\n"), nil
+	})
+
+	statusChan, errChan := client.StreamMemoStatus(context.Background(), "test-uuid")
+	var last MemoStatusResponse
+	for status := range statusChan {
+		last = status
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last.Status != MemoStatusError || last.ErrorReason == nil || *last.ErrorReason != "File format not supported" {
+		t.Errorf("expected error status with reason, got %+v", last)
+	}
+}
+
+func TestStreamMemoStatusInvalidIDType(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		t.Error("expected no request")
+		return nil, nil
+	})
+
+	statusChan, errChan := client.StreamMemoStatus(context.Background(), "test-uuid", IDType("invalid"))
+	for range statusChan {
+	}
+	if err := <-errChan; err == nil {
+		t.Error("expected error for invalid idType")
+	}
+}
+
+func TestChatIncludeSteps(t *testing.T) {
+	include := true
+	exclude := false
+	tests := []struct {
+		name          string
+		includeSteps  *bool
+		expectedFlag  interface{}
+		expectedSteps int
+	}{
+		{name: "unset", expectedSteps: 2},
+		{name: "included", includeSteps: &include, expectedFlag: true, expectedSteps: 2},
+		{name: "disabled", includeSteps: &exclude, expectedFlag: false, expectedSteps: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				var body map[string]interface{}
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request body: %v", err)
+				}
+				if body["include_steps"] != tt.expectedFlag {
+					t.Errorf("expected include_steps %v, got %v", tt.expectedFlag, body["include_steps"])
+				}
+				// The server returns steps regardless of the flag
+				return mockResponse(200, `{"ok": true, "response": "Answer", "intermediate_steps": [{"step": 1}, {"step": 2}]}`), nil
+			})
+
+			resp, err := client.Chat(context.Background(), ChatParams{Query: "test query", IncludeSteps: tt.includeSteps})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resp.IntermediateSteps) != tt.expectedSteps {
+				t.Errorf("expected %d steps, got %d", tt.expectedSteps, len(resp.IntermediateSteps))
+			}
+		})
+	}
+}
+
+func TestChatSeed(t *testing.T) {
+	seed := 42
+	tests := []struct {
+		name      string
+		ragConfig *RAGConfig
+		expected  interface{}
+	}{
+		{name: "set", ragConfig: &RAGConfig{LLMProvider: LLMProviderOpenAI, Seed: &seed}, expected: float64(42)},
+		{name: "unset", ragConfig: &RAGConfig{LLMProvider: LLMProviderOpenAI}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				var body struct {
+					RAGConfig map[string]interface{} `json:"rag_config"`
+				}
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request body: %v", err)
+				}
+				seed, ok := body.RAGConfig["seed"]
+				if tt.expected == nil {
+					if ok {
+						t.Errorf("expected seed to be omitted, got %v", seed)
+					}
+				} else if seed != tt.expected {
+					t.Errorf("expected seed %v, got %v", tt.expected, seed)
+				}
+				return mockResponse(200, `{"ok": true, "response": "Answer"}`), nil
+			})
+
+			if _, err := client.Chat(context.Background(), ChatParams{Query: "test query", RAGConfig: tt.ragConfig}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
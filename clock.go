@@ -0,0 +1,55 @@
+package skald
+
+import (
+	"context"
+	"time"
+)
+
+// clock is the source of time for all time-dependent logic of the client, such
+// as polling, retry delays, the circuit breaker and stream heartbeats, so that
+// tests can replace it
+type clock interface {
+	Now() time.Time
+	// After waits for d to elapse and then sends the current time on the
+	// returned channel
+	After(d time.Duration) <-chan time.Time
+	// NewTicker sends the current time on the returned channel every d, like
+	// time.NewTicker, until the returned stop function is called
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+}
+
+// realClock is the default clock backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+// withClock replaces the client's clock, e.g. with a fake one in tests
+func withClock(clk clock) Option {
+	return func(c *Client) {
+		if clk != nil {
+			c.clock = clk
+		}
+	}
+}
+
+// sleep waits for d on the client's clock, returning early with the context's
+// error if ctx is done
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clock.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+package skald
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock whose waits complete instantly, advancing its time by
+// the waited duration. Its tickers only tick when Tick is called.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	sleeps  []time.Duration
+	tickers []chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	f.sleeps = append(f.sleeps, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- f.now
+	return ch
+}
+
+func (f *fakeClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	f.tickers = append(f.tickers, ch)
+
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i, ticker := range f.tickers {
+			if ticker == ch {
+				f.tickers = append(f.tickers[:i], f.tickers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Tick makes all running tickers tick once. Like with time.Ticker, a tick is
+// dropped if the previous one hasn't been received yet.
+func (f *fakeClock) Tick() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ticker := range f.tickers {
+		select {
+		case ticker <- f.now:
+		default:
+		}
+	}
+}
+
+// Tickers returns the number of running tickers
+func (f *fakeClock) Tickers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.tickers)
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func (f *fakeClock) Sleeps() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Duration(nil), f.sleeps...)
+}
+
+func TestWaitForMemoReadyWithFakeClock(t *testing.T) {
+	var polls atomic.Int32
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if polls.Add(1) < 6 {
+			return mockResponse(200, `{"status": "processing"}`), nil
+		}
+		return mockResponse(200, `{"status": "processed"}`), nil
+	})
+	clk := newFakeClock()
+	withClock(clk)(client)
+
+	start := time.Now()
+	err := client.WaitForMemoReadyWithBackoff(context.Background(), "123e4567-e89b-12d3-a456-426614174000", time.Second, 10*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForMemoReadyWithBackoff failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected polling not to sleep for real, took %v", elapsed)
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	if got := clk.Sleeps(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected waits %v, got %v", want, got)
+	}
+	if polls.Load() != 6 {
+		t.Errorf("expected 6 polls, got %d", polls.Load())
+	}
+}
+
+func TestRetryAfterDateWithFakeClock(t *testing.T) {
+	clk := newFakeClock()
+	var calls atomic.Int32
+	client := newRetryingMockClient(1, func(req *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			resp := mockResponse(503, `{"error": "unavailable"}`)
+			resp.Header.Set("Retry-After", clk.Now().Add(90*time.Second).Format(http.TimeFormat))
+			return resp, nil
+		}
+		return mockResponse(200, `{"uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+	withClock(clk)(client)
+
+	if _, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000"); err != nil {
+		t.Fatalf("GetMemo failed: %v", err)
+	}
+	if got := clk.Sleeps(); !reflect.DeepEqual(got, []time.Duration{90 * time.Second}) {
+		t.Errorf("expected a single 90s wait, got %v", got)
+	}
+}
+
+func TestCircuitBreakerCooldownWithFakeClock(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(500, `{"error": "internal"}`), nil
+	})
+	clk := newFakeClock()
+	withClock(clk)(client)
+	WithCircuitBreaker(1, time.Hour)(client)
+
+	ctx := context.Background()
+	memoID := "123e4567-e89b-12d3-a456-426614174000"
+
+	var apiErr *APIError
+	if _, err := client.GetMemo(ctx, memoID); !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+
+	clk.Advance(59 * time.Minute)
+	if _, err := client.GetMemo(ctx, memoID); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen before the cooldown, got %v", err)
+	}
+
+	clk.Advance(time.Minute)
+	if _, err := client.GetMemo(ctx, memoID); !errors.As(err, &apiErr) {
+		t.Fatalf("expected the request to be sent after the cooldown, got %v", err)
+	}
+}
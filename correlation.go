@@ -0,0 +1,21 @@
+package skald
+
+import "context"
+
+// correlationIDHeader is the header used to send request correlation IDs
+const correlationIDHeader = "X-Correlation-ID"
+
+// correlationIDKey is the context key for correlation IDs
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a copy of ctx carrying the given correlation ID.
+// Requests made with the returned context send it as the X-Correlation-ID header.
+func ContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID carried by ctx, or an empty string
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
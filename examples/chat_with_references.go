@@ -101,15 +101,18 @@ func main() {
 				fullResponse.WriteString(*event.Content)
 			}
 		case "references":
-			// References arrive as JSON in the content
+			// References arrive as JSON in the content, possibly across
+			// several events
+			var refs skald.References
 			if event.Content != nil {
-				if err := json.Unmarshal([]byte(*event.Content), &chatReferences); err != nil {
+				if err := json.Unmarshal([]byte(*event.Content), &refs); err != nil {
 					// Try to use the References field directly if available
-					chatReferences = event.References
+					refs = event.References
 				}
 			} else {
-				chatReferences = event.References
+				refs = event.References
 			}
+			chatReferences = skald.MergeReferences(chatReferences, refs)
 		case "done":
 			chatID = event.ChatID
 		}
@@ -0,0 +1,351 @@
+package skald
+
+import (
+	"crypto/tls"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Option configures a Client created with NewClientWithOptions
+type Option func(*Client)
+
+// NewClientWithOptions creates a new Skald client configured with the given options
+func NewClientWithOptions(apiKey string, opts ...Option) *Client {
+	client := NewClient(apiKey)
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	// Transport settings only apply to the client's own default HTTP client
+	if client.insecureSkipVerify && !client.customHTTPClient {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // explicit opt-in
+		client.httpClient.Transport = transport
+	}
+
+	return client
+}
+
+// NewClientFromEnv creates a new Skald client configured from the environment.
+// SKALD_API_KEY is required. SKALD_BASE_URL optionally sets the base URL and
+// SKALD_TIMEOUT the HTTP timeout, either as a duration ("30s") or a number of
+// seconds ("30"). Options passed explicitly take precedence over the environment,
+// e.g. WithAPIKeyValidation to check the format of the key.
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	apiKey := os.Getenv("SKALD_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("SKALD_API_KEY environment variable not set")
+	}
+
+	var envOpts []Option
+	if baseURL := os.Getenv("SKALD_BASE_URL"); baseURL != "" {
+		envOpts = append(envOpts, WithBaseURL(baseURL))
+	}
+	if value := os.Getenv("SKALD_TIMEOUT"); value != "" {
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SKALD_TIMEOUT: %w", err)
+		}
+		envOpts = append(envOpts, func(c *Client) {
+			c.httpClient.Timeout = timeout
+		})
+	}
+
+	return NewClientWithOptions(apiKey, append(envOpts, opts...)...), nil
+}
+
+// ValidateAPIKeyFormat checks that key looks like an API key, to catch
+// copy-paste mistakes before they surface as a 401 on the first request.
+// Leading and trailing whitespace is ignored. The returned error wraps
+// ErrInvalidAPIKey.
+func ValidateAPIKeyFormat(key string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("%w: key is empty", ErrInvalidAPIKey)
+	}
+	for _, r := range key {
+		if r <= ' ' || r > '~' {
+			return fmt.Errorf("%w: key contains whitespace or non-printable characters", ErrInvalidAPIKey)
+		}
+	}
+	return nil
+}
+
+// parseTimeout parses a duration string, treating bare numbers as seconds
+func parseTimeout(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("timeout must not be negative")
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("timeout must not be negative")
+	}
+	return timeout, nil
+}
+
+// WithBaseURL sets a custom base URL (e.g., for self-hosted instances)
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to make requests. Transport-level
+// options such as WithInsecureSkipVerify have no effect on an injected client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+			c.customHTTPClient = true
+		}
+	}
+}
+
+// WithStrictDecoding makes the client reject API responses containing fields
+// the SDK doesn't know about. This is useful in tests to catch schema drift;
+// by default unknown fields are ignored.
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		c.strictDecoding = true
+	}
+}
+
+// WithMaxResponseSize limits the size of JSON response bodies the client will
+// decode. Larger responses fail with ErrResponseTooLarge. Error response bodies
+// are read up to the limit too, and the message of the returned *APIError is
+// cut there. Streaming chat responses are not limited. A size of zero or less
+// disables the limit.
+func WithMaxResponseSize(bytes int64) Option {
+	return func(c *Client) {
+		c.maxResponseSize = bytes
+	}
+}
+
+// WithCorrelationIDs makes the client send a freshly generated UUID as the
+// X-Correlation-ID header of every request, unless the request context already
+// carries one (see ContextWithCorrelationID). The ID is included in API errors
+// so that client logs can be matched with server logs.
+func WithCorrelationIDs() Option {
+	return func(c *Client) {
+		c.correlationIDs = true
+	}
+}
+
+// WithInsecureSkipVerify disables TLS certificate verification.
+//
+// WARNING: this makes the client vulnerable to man-in-the-middle attacks. Only
+// use it for local development against self-hosted instances with self-signed
+// certificates, never in production. It has no effect when a custom HTTP
+// client is set with WithHTTPClient.
+func WithInsecureSkipVerify() Option {
+	return func(c *Client) {
+		c.insecureSkipVerify = true
+	}
+}
+
+// WithDefaultFilters sets filters that are prepended to the filters of every
+// Search, Chat and StreamedChat call, e.g. to enforce tenant isolation centrally
+func WithDefaultFilters(filters []Filter) Option {
+	return func(c *Client) {
+		c.defaultFilters = append([]Filter(nil), filters...)
+	}
+}
+
+// WithContentDeduplication makes CreateMemo skip creating a memo whose content
+// is identical to an existing memo's, returning the existing memo instead.
+// Content hashes are stored in memo metadata under ContentHashMetadataKey, so
+// only memos created with this option are detected as duplicates.
+func WithContentDeduplication() Option {
+	return func(c *Client) {
+		c.deduplicateContent = true
+	}
+}
+
+// WithFileFieldName sets the multipart form field name under which file uploads
+// are sent (default "file"), for self-hosted endpoints that expect another name
+func WithFileFieldName(name string) Option {
+	return func(c *Client) {
+		if name != "" {
+			c.fileFieldName = name
+		}
+	}
+}
+
+// withMultipartBoundary makes file uploads use a fixed multipart boundary
+// instead of a random one, so that upload bodies are reproducible in tests
+func withMultipartBoundary(boundary string) Option {
+	return func(c *Client) {
+		c.multipartBoundary = boundary
+	}
+}
+
+// WithContentTransformer sets a function that transforms memo content before it
+// is sent by CreateMemo, UpdateMemo and Batch, e.g. to redact PII client-side.
+// With WithContentDeduplication, the transformed content is what gets hashed.
+// Uploaded files are sent unchanged.
+func WithContentTransformer(transform func(string) string) Option {
+	return func(c *Client) {
+		c.contentTransformer = transform
+	}
+}
+
+// WithRetries makes the client retry requests that fail with 429 Too Many
+// Requests or a 500, 502, 503 or 504 server error, up to maxRetries times.
+// Retries respect the Retry-After header and otherwise back off as configured
+// with WithBackoff, by default exponentially with jitter and longer after 503
+// Service Unavailable, which the API returns while in maintenance mode.
+// POST requests, such as creating memos or chatting, are only retried after
+// 429 or after 503 with a Retry-After header, since after other errors the
+// server may have applied them. Streamed file uploads are not retried.
+func WithRetries(maxRetries int) Option {
+	return func(c *Client) {
+		if maxRetries > 0 {
+			c.maxRetries = maxRetries
+		}
+	}
+}
+
+// WithRetryableStatuses replaces the status codes that are retried (see
+// WithRetries), by default 429, 500, 502, 503 and 504, e.g. to also retry 409
+// Conflict for optimistic concurrency or to stop retrying 429. Calling it
+// without status codes keeps the default. POST requests are still only
+// retried after 429 or 503 (see WithRetries).
+func WithRetryableStatuses(statusCodes ...int) Option {
+	return func(c *Client) {
+		if len(statusCodes) == 0 {
+			return
+		}
+		c.retryableStatuses = make(map[int]bool, len(statusCodes))
+		for _, code := range statusCodes {
+			c.retryableStatuses[code] = true
+		}
+	}
+}
+
+// WithAPIKeyValidation checks the API key with ValidateAPIKeyFormat when the
+// client is created. If the key is malformed, every request fails with the
+// validation error instead of being sent.
+func WithAPIKeyValidation() Option {
+	return func(c *Client) {
+		c.apiKeyErr = ValidateAPIKeyFormat(c.apiKey)
+	}
+}
+
+// WithBackoff sets the strategy that decides the delay between retries (see
+// WithRetries). Built-in strategies are ConstantBackoff, ExponentialBackoff
+// (the default) and DecorrelatedJitterBackoff.
+func WithBackoff(strategy BackoffStrategy) Option {
+	return func(c *Client) {
+		if strategy != nil {
+			c.backoff = strategy
+		}
+	}
+}
+
+// WithStreamHeartbeat makes StreamedChat send an event of type "heartbeat" every
+// interval while a stream is active, even if the server sends no data, so that
+// UIs can show that an answer is still being generated. Heartbeats stop before
+// the "done" event.
+func WithStreamHeartbeat(interval time.Duration) Option {
+	return func(c *Client) {
+		c.streamHeartbeat = interval
+	}
+}
+
+// WithStreamPingHandler sets a function that is called with every ping the
+// server sends during a chat stream, e.g. to detect slow backends from the ping
+// intervals. Pings are never sent on the event channel. The handler is called
+// from the goroutine reading the stream and should return quickly.
+func WithStreamPingHandler(handler func(StreamPing)) Option {
+	return func(c *Client) {
+		c.streamPingHandler = handler
+	}
+}
+
+// WithDefaultContextTimeout bounds every request whose context has no deadline
+// (e.g. context.Background()) by the given timeout, to prevent indefinite hangs.
+// Requests whose context already has a deadline are unaffected, and streaming
+// chats are exempt since answers may take arbitrarily long.
+func WithDefaultContextTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.defaultContextTimeout = timeout
+	}
+}
+
+// WithCircuitBreaker makes the client fail fast with ErrCircuitOpen, without
+// sending requests, after threshold consecutive requests fail with a transport
+// error or 5xx response (after any retries) within a window, by default one
+// minute (see WithCircuitBreakerWindow). Requests are let through again once
+// cooldown has elapsed; the breaker closes on the first success and reopens for
+// another cooldown on the next failure.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		if threshold > 0 && cooldown > 0 {
+			window := defaultBreakerWindow
+			if c.breakerWindow > 0 {
+				window = c.breakerWindow
+			}
+			c.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown, window: window}
+		}
+	}
+}
+
+// WithCircuitBreakerWindow sets the window within which the failures counted by
+// the circuit breaker set up with WithCircuitBreaker must occur. Failures that
+// are older don't count towards the threshold.
+func WithCircuitBreakerWindow(window time.Duration) Option {
+	return func(c *Client) {
+		if window <= 0 {
+			return
+		}
+		c.breakerWindow = window
+		if c.breaker != nil {
+			c.breaker.window = window
+		}
+	}
+}
+
+// WithMemoNotifier makes AwaitMemoProcessed wait for notifications from notifier,
+// e.g. backed by a webhook receiver, instead of polling the memo status
+func WithMemoNotifier(notifier MemoNotifier) Option {
+	return func(c *Client) {
+		c.memoNotifier = notifier
+	}
+}
+
+// WithMetadataSchema makes CreateMemo, UpdateMemo and Batch check metadata
+// against the given schema before sending it, failing with ErrInvalidMetadata on
+// undeclared keys or values of the wrong kind (e.g. reflect.String). Numeric
+// kinds are interchangeable, and nil values are allowed.
+func WithMetadataSchema(schema map[string]reflect.Kind) Option {
+	return func(c *Client) {
+		c.metadataSchema = schema
+	}
+}
+
+// WithRandSource makes the built-in backoff strategies draw their jitter from
+// source instead of the global random source, so that retry delays are
+// reproducible, e.g. in tests or load tests, when the source is seeded with a
+// fixed value.
+func WithRandSource(source rand.Source) Option {
+	return func(c *Client) {
+		if source != nil {
+			c.rand = &lockedRand{rng: rand.New(source)}
+		}
+	}
+}
@@ -0,0 +1,775 @@
+package skald
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClientWithOptions(t *testing.T) {
+	client := NewClientWithOptions("test-key", WithBaseURL("https://custom.api.com/"))
+	if client.baseURL != "https://custom.api.com" {
+		t.Errorf("expected baseURL https://custom.api.com, got %q", client.baseURL)
+	}
+	if client.apiKey != "test-key" {
+		t.Errorf("expected apiKey test-key, got %q", client.apiKey)
+	}
+
+	client = NewClientWithOptions("test-key")
+	if client.baseURL != "https://api.useskald.com" {
+		t.Errorf("expected default baseURL, got %q", client.baseURL)
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	t.Run("missing API key", func(t *testing.T) {
+		t.Setenv("SKALD_API_KEY", "")
+
+		_, err := NewClientFromEnv()
+		if err == nil {
+			t.Fatal("expected error when SKALD_API_KEY is not set")
+		}
+		if !strings.Contains(err.Error(), "SKALD_API_KEY") {
+			t.Errorf("expected error to mention SKALD_API_KEY, got %v", err)
+		}
+	})
+
+	t.Run("API key only", func(t *testing.T) {
+		t.Setenv("SKALD_API_KEY", "env-key")
+		t.Setenv("SKALD_BASE_URL", "")
+		t.Setenv("SKALD_TIMEOUT", "")
+
+		client, err := NewClientFromEnv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.apiKey != "env-key" {
+			t.Errorf("expected apiKey env-key, got %q", client.apiKey)
+		}
+		if client.baseURL != "https://api.useskald.com" {
+			t.Errorf("expected default baseURL, got %q", client.baseURL)
+		}
+		if client.httpClient.Timeout != 0 {
+			t.Errorf("expected no timeout, got %v", client.httpClient.Timeout)
+		}
+	})
+
+	t.Run("base URL and timeout", func(t *testing.T) {
+		t.Setenv("SKALD_API_KEY", "env-key")
+		t.Setenv("SKALD_BASE_URL", "https://skald.internal.example.com/")
+		t.Setenv("SKALD_TIMEOUT", "45s")
+
+		client, err := NewClientFromEnv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.baseURL != "https://skald.internal.example.com" {
+			t.Errorf("expected baseURL from env, got %q", client.baseURL)
+		}
+		if client.httpClient.Timeout != 45*time.Second {
+			t.Errorf("expected timeout 45s, got %v", client.httpClient.Timeout)
+		}
+	})
+
+	t.Run("timeout in seconds", func(t *testing.T) {
+		t.Setenv("SKALD_API_KEY", "env-key")
+		t.Setenv("SKALD_TIMEOUT", "30")
+
+		client, err := NewClientFromEnv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.httpClient.Timeout != 30*time.Second {
+			t.Errorf("expected timeout 30s, got %v", client.httpClient.Timeout)
+		}
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		t.Setenv("SKALD_API_KEY", "env-key")
+		t.Setenv("SKALD_TIMEOUT", "soon")
+
+		if _, err := NewClientFromEnv(); err == nil {
+			t.Fatal("expected error for invalid SKALD_TIMEOUT")
+		}
+	})
+
+	t.Run("options override environment", func(t *testing.T) {
+		t.Setenv("SKALD_API_KEY", "env-key")
+		t.Setenv("SKALD_BASE_URL", "https://env.example.com")
+		t.Setenv("SKALD_TIMEOUT", "")
+
+		client, err := NewClientFromEnv(WithBaseURL("https://option.example.com"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.baseURL != "https://option.example.com" {
+			t.Errorf("expected baseURL from option, got %q", client.baseURL)
+		}
+	})
+}
+
+func TestWithStrictDecoding(t *testing.T) {
+	responseBody := `{"status": "processed", "unexpected_field": true}`
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		expectError bool
+	}{
+		{
+			name:        "lenient by default",
+			opts:        nil,
+			expectError: false,
+		},
+		{
+			name:        "strict rejects unknown fields",
+			opts:        []Option{WithStrictDecoding()},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				return mockResponse(200, responseBody), nil
+			})
+			for _, opt := range tt.opts {
+				opt(client)
+			}
+
+			status, err := client.CheckMemoStatus(context.Background(), "test-uuid")
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("expected decode error for unknown field")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if status.Status != MemoStatusProcessed {
+				t.Errorf("expected status processed, got %s", status.Status)
+			}
+		})
+	}
+}
+
+func TestWithMaxResponseSize(t *testing.T) {
+	item := `{"uuid": "test-uuid", "title": "Test Memo", "summary": "Test summary", "metadata": {}}`
+	items := make([]string, 100)
+	for i := range items {
+		items[i] = item
+	}
+	listBody := `{"count": 100, "next": null, "previous": null, "results": [` + strings.Join(items, ",") + `]}`
+
+	tests := []struct {
+		name        string
+		maxSize     int64
+		expectError bool
+	}{
+		{
+			name:        "unlimited by default",
+			maxSize:     0,
+			expectError: false,
+		},
+		{
+			name:        "limit above response size",
+			maxSize:     int64(len(listBody)),
+			expectError: false,
+		},
+		{
+			name:        "limit below response size",
+			maxSize:     1024,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				return mockResponse(200, listBody), nil
+			})
+			WithMaxResponseSize(tt.maxSize)(client)
+
+			resp, err := client.ListMemos(context.Background(), nil)
+			if tt.expectError {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resp.Results) != 100 {
+				t.Errorf("expected 100 results, got %d", len(resp.Results))
+			}
+		})
+	}
+}
+
+func TestWithMaxResponseSizeErrorBody(t *testing.T) {
+	body := &countingReader{r: strings.NewReader(`{"error": "` + strings.Repeat("x", 1<<20) + `"}`)}
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 500, Header: make(http.Header), Body: io.NopCloser(body)}, nil
+	})
+	WithMaxResponseSize(1024)(client)
+
+	_, err := client.GetMemo(context.Background(), "test-uuid")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Fatalf("expected APIError with status 500, got %v", err)
+	}
+	if len(apiErr.Message) != 1024 {
+		t.Errorf("expected the message to be cut at 1024 bytes, got %d", len(apiErr.Message))
+	}
+	if body.n > 1024+maxDrainSize+1 {
+		t.Errorf("expected the error body not to be read in full, read %d bytes", body.n)
+	}
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestWithCorrelationIDs(t *testing.T) {
+	var sentIDs []string
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		sentIDs = append(sentIDs, req.Header.Get("X-Correlation-ID"))
+		resp := mockResponse(500, `{"error": "internal"}`)
+		resp.Request = req
+		return resp, nil
+	})
+	WithCorrelationIDs()(client)
+
+	_, err := client.GetMemo(context.Background(), "test-uuid")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	_, _ = client.GetMemo(context.Background(), "test-uuid")
+
+	if len(sentIDs) != 2 || sentIDs[0] == "" || sentIDs[1] == "" {
+		t.Fatalf("expected a correlation ID on every request, got %v", sentIDs)
+	}
+	if sentIDs[0] == sentIDs[1] {
+		t.Error("expected a new correlation ID per request")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if apiErr.CorrelationID != sentIDs[0] {
+		t.Errorf("expected error to carry correlation ID %q, got %q", sentIDs[0], apiErr.CorrelationID)
+	}
+	if !strings.Contains(apiErr.Error(), sentIDs[0]) {
+		t.Errorf("expected error message to include correlation ID, got %q", apiErr.Error())
+	}
+}
+
+func TestCorrelationIDFromContext(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "with generated IDs enabled", opts: []Option{WithCorrelationIDs()}},
+		{name: "without generated IDs", opts: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				if got := req.Header.Get("X-Correlation-ID"); got != "trace-abc" {
+					t.Errorf("expected context correlation ID trace-abc, got %q", got)
+				}
+				return mockResponse(200, `{"status": "processed"}`), nil
+			})
+			for _, opt := range tt.opts {
+				opt(client)
+			}
+
+			ctx := ContextWithCorrelationID(context.Background(), "trace-abc")
+			if _, err := client.CheckMemoStatus(ctx, "test-uuid"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNoCorrelationIDByDefault(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if _, ok := req.Header["X-Correlation-Id"]; ok {
+			t.Error("expected no correlation ID header by default")
+		}
+		return mockResponse(200, `{"status": "processed"}`), nil
+	})
+
+	if _, err := client.CheckMemoStatus(context.Background(), "test-uuid"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	httpClient := &http.Client{}
+	client := NewClientWithOptions("test-key", WithHTTPClient(httpClient))
+	if client.httpClient != httpClient {
+		t.Error("expected injected HTTP client to be used")
+	}
+}
+
+func TestWithInsecureSkipVerify(t *testing.T) {
+	client := NewClientWithOptions("test-key")
+	if client.httpClient.Transport != nil {
+		t.Error("expected default transport without WithInsecureSkipVerify")
+	}
+
+	client = NewClientWithOptions("test-key", WithInsecureSkipVerify())
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", client.httpClient.Transport)
+	}
+	if transport.TLSClientConfig == nil || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("expected InsecureSkipVerify to be set on the transport")
+	}
+
+	if defaultTransport := http.DefaultTransport.(*http.Transport); defaultTransport.TLSClientConfig != nil && defaultTransport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("expected http.DefaultTransport to be left untouched")
+	}
+}
+
+func TestWithInsecureSkipVerifyCustomClient(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithHTTPClient(&http.Client{}), WithInsecureSkipVerify()},
+		{WithInsecureSkipVerify(), WithHTTPClient(&http.Client{})},
+	} {
+		client := NewClientWithOptions("test-key", opts...)
+		if client.httpClient.Transport != nil {
+			t.Errorf("expected injected client transport to be left untouched, got %T", client.httpClient.Transport)
+		}
+	}
+}
+
+func TestWithDefaultFilters(t *testing.T) {
+	tenant := Filter{Field: "tenant", Operator: FilterOperatorEq, Value: "acme", FilterType: FilterTypeCustomMetadata}
+	source := Filter{Field: "source", Operator: FilterOperatorEq, Value: "notion", FilterType: FilterTypeNativeField}
+
+	var captured [][]Filter
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Filters []Filter `json:"filters"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		captured = append(captured, body.Filters)
+		if req.URL.Path == "/api/v1/search" {
+			return mockResponse(200, `{"results": []}`), nil
+		}
+		return mockResponse(200, `{"ok": true, "response": "Answer"}`), nil
+	})
+	WithDefaultFilters([]Filter{tenant})(client)
+
+	if _, err := client.Search(context.Background(), SearchRequest{Query: "test"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Search(context.Background(), SearchRequest{Query: "test", Filters: []Filter{source}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Chat(context.Background(), ChatParams{Query: "test", Filters: []Filter{source}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [][]string{{"tenant"}, {"tenant", "source"}, {"tenant", "source"}}
+	if len(captured) != len(expected) {
+		t.Fatalf("expected %d requests, got %d", len(expected), len(captured))
+	}
+	for i, fields := range expected {
+		if len(captured[i]) != len(fields) {
+			t.Errorf("request %d: expected filters on %v, got %+v", i, fields, captured[i])
+			continue
+		}
+		for j, field := range fields {
+			if captured[i][j].Field != field {
+				t.Errorf("request %d: expected filter %d on %q, got %q", i, j, field, captured[i][j].Field)
+			}
+		}
+	}
+}
+
+func TestWithContentDeduplication(t *testing.T) {
+	t.Run("skips duplicate", func(t *testing.T) {
+		var methods []string
+		client := newMockClient(func(req *http.Request) (*http.Response, error) {
+			methods = append(methods, req.Method)
+			return mockResponse(200, `{"count": 1, "results": [{"uuid": "123e4567-e89b-12d3-a456-426614174000"}]}`), nil
+		})
+		WithContentDeduplication()(client)
+
+		resp, err := client.CreateMemo(context.Background(), MemoData{Title: "Test", Content: "Duplicate content"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.MemoUUID.String() != "123e4567-e89b-12d3-a456-426614174000" {
+			t.Errorf("expected existing memo UUID, got %s", resp.MemoUUID)
+		}
+		if len(methods) != 1 || methods[0] != "GET" {
+			t.Errorf("expected only a lookup request, got %v", methods)
+		}
+	})
+
+	t.Run("creates new content with hash", func(t *testing.T) {
+		metadata := map[string]interface{}{"author": "jane"}
+		client := newMockClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method == "GET" {
+				return mockResponse(200, `{"count": 0, "results": []}`), nil
+			}
+			var memoData MemoData
+			if err := json.NewDecoder(req.Body).Decode(&memoData); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			if memoData.Metadata[ContentHashMetadataKey] != HashContent("New content") {
+				t.Errorf("expected content hash in metadata, got %v", memoData.Metadata)
+			}
+			if memoData.Metadata["author"] != "jane" {
+				t.Errorf("expected existing metadata to be kept, got %v", memoData.Metadata)
+			}
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174001"}`), nil
+		})
+		WithContentDeduplication()(client)
+
+		resp, err := client.CreateMemo(context.Background(), MemoData{Title: "Test", Content: "New content", Metadata: metadata})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.MemoUUID.String() != "123e4567-e89b-12d3-a456-426614174001" {
+			t.Errorf("expected new memo UUID, got %s", resp.MemoUUID)
+		}
+		if _, ok := metadata[ContentHashMetadataKey]; ok {
+			t.Error("expected caller's metadata map to be left unmodified")
+		}
+	})
+}
+
+func TestWithFileFieldName(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		expectedField string
+	}{
+		{name: "default", opts: nil, expectedField: "file"},
+		{name: "custom", opts: []Option{WithFileFieldName("document")}, expectedField: "document"},
+		{name: "empty keeps default", opts: []Option{WithFileFieldName("")}, expectedField: "file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				if err := req.ParseMultipartForm(1 << 20); err != nil {
+					t.Fatalf("failed to parse multipart form: %v", err)
+				}
+				if len(req.MultipartForm.File) != 1 {
+					t.Errorf("expected exactly one file field, got %d", len(req.MultipartForm.File))
+				}
+				files := req.MultipartForm.File[tt.expectedField]
+				if len(files) != 1 || files[0].Filename != "notes.txt" {
+					t.Errorf("expected file notes.txt in field %q, got %v", tt.expectedField, req.MultipartForm.File)
+				}
+				return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+			})
+			for _, opt := range tt.opts {
+				opt(client)
+			}
+
+			_, err := client.CreateMemoFromReader(context.Background(), strings.NewReader("content"), "notes.txt", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithContentTransformer(t *testing.T) {
+	redact := func(content string) string {
+		return strings.ReplaceAll(content, "555-0100", "[REDACTED]")
+	}
+
+	t.Run("create", func(t *testing.T) {
+		client := newMockClient(func(req *http.Request) (*http.Response, error) {
+			var memoData MemoData
+			if err := json.NewDecoder(req.Body).Decode(&memoData); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			if memoData.Content != "Call me at [REDACTED]" {
+				t.Errorf("expected redacted content, got %q", memoData.Content)
+			}
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		})
+		WithContentTransformer(redact)(client)
+
+		if _, err := client.CreateMemo(context.Background(), MemoData{Title: "Test", Content: "Call me at 555-0100"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		client := newMockClient(func(req *http.Request) (*http.Response, error) {
+			var updateData UpdateMemoData
+			if err := json.NewDecoder(req.Body).Decode(&updateData); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+			if updateData.Content == nil || *updateData.Content != "Call me at [REDACTED]" {
+				t.Errorf("expected redacted content, got %v", updateData.Content)
+			}
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		})
+		WithContentTransformer(redact)(client)
+
+		content := "Call me at 555-0100"
+		_, err := client.UpdateMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000", UpdateMemoData{Content: &content})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if content != "Call me at 555-0100" {
+			t.Error("expected caller's content to be left unmodified")
+		}
+	})
+
+	t.Run("update without content", func(t *testing.T) {
+		called := false
+		client := newMockClient(func(req *http.Request) (*http.Response, error) {
+			return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		})
+		WithContentTransformer(func(content string) string {
+			called = true
+			return content
+		})(client)
+
+		title := "New title"
+		_, err := client.UpdateMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000", UpdateMemoData{Title: &title})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if called {
+			t.Error("expected transformer not to run without content")
+		}
+	})
+}
+
+func TestValidateAPIKeyFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		expectError bool
+	}{
+		{name: "well-formed", key: "sk_live_0123456789abcdef", expectError: false},
+		{name: "whitespace-padded", key: "  sk_live_0123456789abcdef\n", expectError: false},
+		{name: "empty", key: "", expectError: true},
+		{name: "only whitespace", key: " \n\t", expectError: true},
+		{name: "inner whitespace", key: "Bearer sk_live_0123456789abcdef", expectError: true},
+		{name: "inner newline", key: "sk_live_01234567\n89abcdef", expectError: true},
+		{name: "non-ASCII", key: "sk_live_0123456789abcdéf", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAPIKeyFormat(tt.key)
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidAPIKey) {
+					t.Errorf("expected ErrInvalidAPIKey, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithAPIKeyValidation(t *testing.T) {
+	calls := 0
+	client := NewClientWithOptions("not a key", WithAPIKeyValidation())
+	client.httpClient = &http.Client{Transport: &mockRoundTripper{roundTripFunc: func(req *http.Request) (*http.Response, error) {
+		calls++
+		return mockResponse(200, `{}`), nil
+	}}}
+
+	_, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000")
+	if !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("expected ErrInvalidAPIKey, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected no request to be sent, got %d", calls)
+	}
+
+	client = NewClientWithOptions("sk_live_0123456789abcdef", WithAPIKeyValidation())
+	if client.apiKeyErr != nil {
+		t.Errorf("unexpected validation error for well-formed key: %v", client.apiKeyErr)
+	}
+}
+
+func TestNewClientFromEnvWithAPIKeyValidation(t *testing.T) {
+	t.Setenv("SKALD_API_KEY", "not a key")
+
+	client, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("expected the key not to be validated by default, got %v", err)
+	}
+	if client.apiKeyErr != nil {
+		t.Errorf("unexpected validation error: %v", client.apiKeyErr)
+	}
+
+	client, err = NewClientFromEnv(WithAPIKeyValidation())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(client.apiKeyErr, ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey, got %v", client.apiKeyErr)
+	}
+}
+
+func TestWithDefaultContextTimeout(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		deadline, hasDeadline = req.Context().Deadline()
+		if req.URL.Path == "/api/v1/chat" {
+			return mockResponse(200, "data: {\"type\":\"done\"}\n"), nil
+		}
+		return mockResponse(200, `{"uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+	WithDefaultContextTimeout(time.Minute)(client)
+
+	t.Run("applied without deadline", func(t *testing.T) {
+		start := time.Now()
+		if _, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !hasDeadline {
+			t.Fatal("expected a deadline to be applied")
+		}
+		if remaining := deadline.Sub(start); remaining < 55*time.Second || remaining > time.Minute+time.Second {
+			t.Errorf("expected a deadline about a minute away, got %v", remaining)
+		}
+	})
+
+	t.Run("caller deadline kept", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		expected, _ := ctx.Deadline()
+
+		if _, err := client.GetMemo(ctx, "123e4567-e89b-12d3-a456-426614174000"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !hasDeadline || !deadline.Equal(expected) {
+			t.Errorf("expected caller's deadline %v, got %v", expected, deadline)
+		}
+	})
+
+	t.Run("streaming exempt", func(t *testing.T) {
+		eventChan, errChan := client.StreamedChat(context.Background(), ChatParams{Query: "test"})
+		for range eventChan {
+		}
+		if err := <-errChan; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if hasDeadline {
+			t.Error("expected no deadline for streaming chat")
+		}
+	})
+}
+
+func TestDefaultContextTimeoutExpires(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	WithDefaultContextTimeout(10 * time.Millisecond)(client)
+
+	_, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestWithMetadataSchema(t *testing.T) {
+	schema := map[string]reflect.Kind{
+		"author":   reflect.String,
+		"year":     reflect.Int,
+		"reviewed": reflect.Bool,
+		"tags":     reflect.Slice,
+	}
+
+	tests := []struct {
+		name        string
+		metadata    map[string]interface{}
+		expectError bool
+	}{
+		{name: "conforming", metadata: map[string]interface{}{"author": "jane", "year": 2026, "reviewed": true, "tags": []string{"a"}}},
+		{name: "numeric kinds interchangeable", metadata: map[string]interface{}{"year": 2026.0}},
+		{name: "nil value", metadata: map[string]interface{}{"author": nil}},
+		{name: "no metadata", metadata: nil},
+		{name: "undeclared key", metadata: map[string]interface{}{"author": "jane", "autor": "jane"}, expectError: true},
+		{name: "wrong kind", metadata: map[string]interface{}{"year": "2026"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			client := newMockClient(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+			})
+			WithMetadataSchema(schema)(client)
+
+			_, createErr := client.CreateMemo(context.Background(), MemoData{Title: "Title", Content: "Content", Metadata: tt.metadata})
+			_, updateErr := client.UpdateMemo(context.Background(), "test-uuid", UpdateMemoData{Metadata: tt.metadata})
+
+			for _, err := range []error{createErr, updateErr} {
+				if tt.expectError {
+					if !errors.Is(err, ErrInvalidMetadata) {
+						t.Errorf("expected ErrInvalidMetadata, got %v", err)
+					}
+				} else if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+
+			expectedRequests := 2
+			if tt.expectError {
+				expectedRequests = 0
+			}
+			if requests != expectedRequests {
+				t.Errorf("expected %d requests, got %d", expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestWithMetadataSchemaContentDeduplication(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == "GET" {
+			return mockResponse(200, `{"count": 0, "results": []}`), nil
+		}
+		return mockResponse(200, `{"memo_uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+	})
+	WithMetadataSchema(map[string]reflect.Kind{"author": reflect.String})(client)
+	WithContentDeduplication()(client)
+
+	// The content hash added by the client isn't part of the schema
+	if _, err := client.CreateMemo(context.Background(), MemoData{Title: "Title", Content: "Content", Metadata: map[string]interface{}{"author": "jane"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
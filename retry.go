@@ -0,0 +1,216 @@
+package skald
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRetryBaseDelay is the delay before the first retry
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	// defaultRetryMaxDelay caps the delay between retries
+	defaultRetryMaxDelay = 30 * time.Second
+	// serviceUnavailableBackoffFactor lengthens the delay after a 503, which
+	// the API returns while in maintenance mode during deploys
+	serviceUnavailableBackoffFactor = 4
+)
+
+// BackoffStrategy decides how long to wait before retrying a request. attempt
+// is zero for the first retry, and resp is the response that is being retried.
+type BackoffStrategy interface {
+	NextDelay(attempt int, resp *http.Response) time.Duration
+}
+
+// ConstantBackoff waits the same delay before every retry
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// NextDelay implements BackoffStrategy
+func (b ConstantBackoff) NextDelay(attempt int, resp *http.Response) time.Duration {
+	return b.Delay
+}
+
+// ExponentialBackoff doubles the delay with every retry, starting at Initial and
+// capped at Max, and randomizes each delay to between half and all of it. After
+// a 503 Service Unavailable, which the API returns while in maintenance mode,
+// the delays are four times as long. This is the default strategy.
+type ExponentialBackoff struct {
+	Initial time.Duration
+	Max     time.Duration
+
+	rng *lockedRand
+}
+
+// NextDelay implements BackoffStrategy
+func (b ExponentialBackoff) NextDelay(attempt int, resp *http.Response) time.Duration {
+	initial := b.Initial
+	if resp != nil && resp.StatusCode == http.StatusServiceUnavailable {
+		initial *= serviceUnavailableBackoffFactor
+	}
+	delay := backoffInterval(initial, b.Max, attempt)
+
+	// Equal jitter: wait between half and the full delay
+	half := delay / 2
+	return half + time.Duration(b.rng.Int63n(int64(half)+1))
+}
+
+func (b ExponentialBackoff) withRand(rng *lockedRand) BackoffStrategy {
+	b.rng = rng
+	return b
+}
+
+// DecorrelatedJitterBackoff waits a random delay between Base and three times
+// the previous maximum, capped at Max, which spreads out retries from many
+// clients more than ExponentialBackoff does
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	rng *lockedRand
+}
+
+// NextDelay implements BackoffStrategy
+func (b DecorrelatedJitterBackoff) NextDelay(attempt int, resp *http.Response) time.Duration {
+	upper := b.Base
+	for i := 0; i <= attempt && upper < b.Max; i++ {
+		upper *= 3
+	}
+	upper = min(upper, b.Max)
+	if upper <= b.Base {
+		return b.Base
+	}
+	return b.Base + time.Duration(b.rng.Int63n(int64(upper-b.Base)+1))
+}
+
+func (b DecorrelatedJitterBackoff) withRand(rng *lockedRand) BackoffStrategy {
+	b.rng = rng
+	return b
+}
+
+// randomizedBackoff is a BackoffStrategy whose randomness can be drawn from
+// the source set with WithRandSource
+type randomizedBackoff interface {
+	withRand(rng *lockedRand) BackoffStrategy
+}
+
+// lockedRand is a random number generator that is safe for concurrent use.
+// A nil *lockedRand uses the global source of math/rand.
+type lockedRand struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// Int63n returns a random number in [0, n)
+func (r *lockedRand) Int63n(n int64) int64 {
+	if r == nil {
+		return rand.Int63n(n)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Int63n(n)
+}
+
+// defaultBackoff is the backoff strategy used unless one is set with WithBackoff
+var defaultBackoff BackoffStrategy = ExponentialBackoff{
+	Initial: defaultRetryBaseDelay,
+	Max:     defaultRetryMaxDelay,
+}
+
+// isRetryableStatus reports whether a response with the given status code
+// should be retried, using the statuses set with WithRetryableStatuses if any
+func (c *Client) isRetryableStatus(statusCode int) bool {
+	if c.retryableStatuses != nil {
+		return c.retryableStatuses[statusCode]
+	}
+	return isDefaultRetryableStatus(statusCode)
+}
+
+// shouldRetry reports whether resp to req should be retried. POST requests
+// aren't idempotent: a 500 may come after the server applied the request, and
+// resending it could e.g. append content twice or create a duplicate memo. So
+// they are only retried when the server signals that it didn't process them:
+// with 429 Too Many Requests, or 503 Service Unavailable and a Retry-After
+// header.
+func (c *Client) shouldRetry(req *http.Request, resp *http.Response) bool {
+	if !c.isRetryableStatus(resp.StatusCode) {
+		return false
+	}
+	if req.Method != http.MethodPost {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable:
+		return resp.Header.Get("Retry-After") != ""
+	}
+	return false
+}
+
+// isDefaultRetryableStatus reports whether a response with the given status
+// code is retried by default
+func isDefaultRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before retrying after resp. A Retry-After
+// header is respected; otherwise the delay is decided by the backoff strategy.
+func (c *Client) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), c.clock.Now()); ok {
+		return delay
+	}
+	backoff := c.backoff
+	if randomized, ok := backoff.(randomizedBackoff); ok && c.rand != nil {
+		backoff = randomized.withRand(c.rand)
+	}
+	return backoff.NextDelay(attempt, resp)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP
+// date, which is resolved relative to now
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// newRetryRequest returns a copy of req with a fresh body for resending it.
+// It returns false if the body can't be replayed, e.g. for streamed uploads.
+func newRetryRequest(req *http.Request) (*http.Request, bool) {
+	retryReq := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retryReq, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retryReq.Body = body
+	return retryReq, true
+}
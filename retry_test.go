@@ -0,0 +1,381 @@
+package skald
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRetryingMockClient creates a mock client with fast retries
+func newRetryingMockClient(maxRetries int, roundTripFunc func(req *http.Request) (*http.Response, error)) *Client {
+	client := newMockClient(roundTripFunc)
+	WithRetries(maxRetries)(client)
+	WithBackoff(ConstantBackoff{Delay: time.Millisecond})(client)
+	return client
+}
+
+func TestServiceUnavailable(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(503, `{"error": "Down for maintenance"}`), nil
+	})
+
+	_, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	if !apiErr.IsServiceUnavailable() {
+		t.Error("expected IsServiceUnavailable to be true")
+	}
+	if !strings.Contains(apiErr.Message, "maintenance") {
+		t.Errorf("expected maintenance message, got %q", apiErr.Message)
+	}
+	if (&APIError{StatusCode: 500}).IsServiceUnavailable() {
+		t.Error("expected IsServiceUnavailable to be false for 500")
+	}
+}
+
+func TestRetriesServiceUnavailable(t *testing.T) {
+	calls := 0
+	client := newRetryingMockClient(3, func(req *http.Request) (*http.Response, error) {
+		calls++
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		if !strings.Contains(string(body), `"query":"test"`) {
+			t.Errorf("expected request body to be resent, got %s", body)
+		}
+		if calls < 3 {
+			resp := mockResponse(503, `{"error": "Down for maintenance"}`)
+			resp.Header.Set("Retry-After", "0")
+			return resp, nil
+		}
+		return mockResponse(200, `{"results": []}`), nil
+	})
+
+	if _, err := client.Search(context.Background(), SearchRequest{Query: "test"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	calls := 0
+	client := newRetryingMockClient(2, func(req *http.Request) (*http.Response, error) {
+		calls++
+		return mockResponse(503, `{"error": "Down for maintenance"}`), nil
+	})
+
+	_, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsServiceUnavailable() {
+		t.Fatalf("expected service unavailable error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestNoRetriesByDefault(t *testing.T) {
+	calls := 0
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return mockResponse(503, `{"error": "Down for maintenance"}`), nil
+	})
+
+	if _, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000"); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	calls := 0
+	client := newRetryingMockClient(3, func(req *http.Request) (*http.Response, error) {
+		calls++
+		return mockResponse(404, `{"error": "not found"}`), nil
+	})
+
+	if _, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000"); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestWithRetryableStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		status   int
+		attempts int
+	}{
+		{"adds 409", []int{409, 503}, 409, 3},
+		{"keeps listed status", []int{409, 503}, 503, 3},
+		{"removes 429", []int{500, 502, 503, 504}, 429, 1},
+		{"no statuses keeps default", nil, 429, 3},
+		{"default does not retry 409", nil, 409, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := newRetryingMockClient(2, func(req *http.Request) (*http.Response, error) {
+				calls++
+				return mockResponse(tt.status, `{"error": "failed"}`), nil
+			})
+			WithRetryableStatuses(tt.statuses...)(client)
+
+			if _, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000"); err == nil {
+				t.Fatal("expected error")
+			}
+			if calls != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, calls)
+			}
+		})
+	}
+}
+
+func TestPostRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		attempts   int
+	}{
+		{"retries 429", 429, "", 3},
+		{"retries 503 with Retry-After", 503, "0", 3},
+		{"no retry of 503 without Retry-After", 503, "", 1},
+		{"no retry of 500", 500, "", 1},
+		{"no retry of 502", 502, "", 1},
+		{"no retry of 504", 504, "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := newRetryingMockClient(2, func(req *http.Request) (*http.Response, error) {
+				calls++
+				resp := mockResponse(tt.status, `{"error": "failed"}`)
+				if tt.retryAfter != "" {
+					resp.Header.Set("Retry-After", tt.retryAfter)
+				}
+				return resp, nil
+			})
+
+			if _, err := client.AppendMemoContent(context.Background(), "123e4567-e89b-12d3-a456-426614174000", "entry"); err == nil {
+				t.Fatal("expected error")
+			}
+			if calls != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, calls)
+			}
+		})
+	}
+}
+
+func TestCreateMemoServerErrorWithRetries(t *testing.T) {
+	var posts, gets int
+	client := newRetryingMockClient(3, func(req *http.Request) (*http.Response, error) {
+		if req.Method == "GET" {
+			gets++
+			if req.URL.Query().Get("id_type") != "reference_id" {
+				t.Errorf("expected lookup by reference ID, got %s", req.URL.RawQuery)
+			}
+			return mockResponse(200, `{"uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		}
+		posts++
+		if posts == 1 {
+			return mockResponse(500, `{"error": "internal"}`), nil
+		}
+		return mockResponse(409, `{"error": "reference_id already exists"}`), nil
+	})
+
+	refID := "ref-1"
+	result, err := client.CreateMemo(context.Background(), MemoData{
+		Title:       "Test",
+		Content:     "Content",
+		ReferenceID: &refID,
+	})
+	if err != nil {
+		t.Fatalf("expected the existing memo, got %v", err)
+	}
+	if result.MemoUUID.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("unexpected memo UUID %s", result.MemoUUID)
+	}
+	if posts != 1 {
+		t.Errorf("expected the create not to be resent, got %d POSTs", posts)
+	}
+	if gets != 1 {
+		t.Errorf("expected 1 lookup, got %d", gets)
+	}
+}
+
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		return mockResponse(503, `{"error": "Down for maintenance"}`), nil
+	})
+	WithRetries(3)(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetMemo(ctx, "123e4567-e89b-12d3-a456-426614174000")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("expected retry wait to stop when the context is done")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	client := NewClient("test-api-key")
+
+	resp := &http.Response{StatusCode: 503, Header: make(http.Header)}
+	resp.Header.Set("Retry-After", "120")
+	if delay := client.retryDelay(0, resp); delay != 120*time.Second {
+		t.Errorf("expected Retry-After delay of 120s, got %v", delay)
+	}
+
+	WithBackoff(ConstantBackoff{Delay: 7 * time.Second})(client)
+	if delay := client.retryDelay(0, &http.Response{StatusCode: 500, Header: make(http.Header)}); delay != 7*time.Second {
+		t.Errorf("expected backoff strategy delay of 7s, got %v", delay)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff{Initial: 500 * time.Millisecond, Max: 30 * time.Second}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		unavailable := backoff.NextDelay(attempt, &http.Response{StatusCode: 503})
+		serverError := backoff.NextDelay(attempt, &http.Response{StatusCode: 500})
+		if unavailable <= serverError {
+			t.Errorf("attempt %d: expected 503 delay %v to be longer than 500 delay %v", attempt, unavailable, serverError)
+		}
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		expected := backoffInterval(backoff.Initial, backoff.Max, attempt)
+		delay := backoff.NextDelay(attempt, &http.Response{StatusCode: 500})
+		if delay < expected/2 || delay > expected {
+			t.Errorf("attempt %d: expected delay between %v and %v, got %v", attempt, expected/2, expected, delay)
+		}
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	backoff := DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Max: 2 * time.Second}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := backoff.NextDelay(attempt, nil)
+		if delay < backoff.Base || delay > backoff.Max {
+			t.Errorf("attempt %d: expected delay between %v and %v, got %v", attempt, backoff.Base, backoff.Max, delay)
+		}
+	}
+	if delay := backoff.NextDelay(0, nil); delay > 300*time.Millisecond {
+		t.Errorf("expected first delay of at most 300ms, got %v", delay)
+	}
+}
+
+func TestWithRandSource(t *testing.T) {
+	delays := func(seed int64, strategy BackoffStrategy) []time.Duration {
+		client := NewClientWithOptions("test-api-key", WithBackoff(strategy), WithRandSource(rand.NewSource(seed)))
+		var delays []time.Duration
+		for attempt := 0; attempt < 8; attempt++ {
+			delays = append(delays, client.retryDelay(attempt, &http.Response{StatusCode: 500, Header: make(http.Header)}))
+		}
+		return delays
+	}
+
+	strategies := map[string]BackoffStrategy{
+		"exponential":         ExponentialBackoff{Initial: 500 * time.Millisecond, Max: 30 * time.Second},
+		"decorrelated jitter": DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Max: 10 * time.Second},
+	}
+	for name, strategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			first, second := delays(42, strategy), delays(42, strategy)
+			if !reflect.DeepEqual(first, second) {
+				t.Errorf("expected identical delays with the same seed, got %v and %v", first, second)
+			}
+			if other := delays(7, strategy); reflect.DeepEqual(first, other) {
+				t.Errorf("expected different delays with a different seed, got %v for both", first)
+			}
+		})
+	}
+
+	t.Run("default strategy", func(t *testing.T) {
+		newClient := func() *Client {
+			return NewClientWithOptions("test-api-key", WithRandSource(rand.NewSource(42)))
+		}
+		first, second := newClient(), newClient()
+		for attempt := 0; attempt < 8; attempt++ {
+			resp := &http.Response{StatusCode: 503, Header: make(http.Header)}
+			if a, b := first.retryDelay(attempt, resp), second.retryDelay(attempt, resp); a != b {
+				t.Errorf("attempt %d: expected identical delays, got %v and %v", attempt, a, b)
+			}
+		}
+	})
+}
+
+// recordingBackoff is a BackoffStrategy that records the attempts and statuses
+// it is asked about and returns increasing delays
+type recordingBackoff struct {
+	attempts []int
+	statuses []int
+}
+
+func (b *recordingBackoff) NextDelay(attempt int, resp *http.Response) time.Duration {
+	b.attempts = append(b.attempts, attempt)
+	b.statuses = append(b.statuses, resp.StatusCode)
+	return time.Duration(attempt+1) * time.Millisecond
+}
+
+func TestWithBackoff(t *testing.T) {
+	statuses := []int{429, 502, 503, 200}
+	calls := 0
+	var callTimes []time.Time
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		callTimes = append(callTimes, time.Now())
+		status := statuses[calls]
+		calls++
+		if status == 200 {
+			return mockResponse(200, `{"uuid": "123e4567-e89b-12d3-a456-426614174000"}`), nil
+		}
+		return mockResponse(status, `{"error": "try again"}`), nil
+	})
+	backoff := &recordingBackoff{}
+	WithRetries(5)(client)
+	WithBackoff(backoff)(client)
+
+	if _, err := client.GetMemo(context.Background(), "123e4567-e89b-12d3-a456-426614174000"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 4 {
+		t.Fatalf("expected 4 attempts, got %d", calls)
+	}
+	if len(backoff.attempts) != 3 || backoff.attempts[0] != 0 || backoff.attempts[1] != 1 || backoff.attempts[2] != 2 {
+		t.Errorf("expected attempts [0 1 2], got %v", backoff.attempts)
+	}
+	if len(backoff.statuses) != 3 || backoff.statuses[0] != 429 || backoff.statuses[1] != 502 || backoff.statuses[2] != 503 {
+		t.Errorf("expected statuses [429 502 503], got %v", backoff.statuses)
+	}
+	for i := 1; i < len(callTimes); i++ {
+		if gap := callTimes[i].Sub(callTimes[i-1]); gap < time.Duration(i)*time.Millisecond {
+			t.Errorf("expected at least %v between attempts %d and %d, got %v", time.Duration(i)*time.Millisecond, i-1, i, gap)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package skald
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AuthenticatedHTTPClient returns an *http.Client for tools and libraries that
+// drive the Skald API directly. Requests to the client's base URL are sent like
+// the client's own requests: with the Authorization header, correlation IDs,
+// retries and the other configured options. Requests to any other URL are sent
+// unchanged, so the API key is never leaked to other hosts.
+func (c *Client) AuthenticatedHTTPClient() *http.Client {
+	return &http.Client{Transport: &authTransport{client: c}}
+}
+
+// authTransport is the transport of AuthenticatedHTTPClient
+type authTransport struct {
+	client *Client
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.client.targetsBaseURL(req.URL) {
+		transport := t.client.httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		return transport.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it is given
+	return t.client.do(req.Clone(req.Context()))
+}
+
+// targetsBaseURL reports whether u points at the client's base URL, comparing
+// the scheme, host and path rather than string prefixes so that e.g.
+// "https://api.useskald.com.example.com" doesn't match
+func (c *Client) targetsBaseURL(u *url.URL) bool {
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) {
+		return false
+	}
+
+	basePath := strings.TrimRight(base.Path, "/")
+	return u.Path == basePath || strings.HasPrefix(u.Path, basePath+"/")
+}
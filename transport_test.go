@@ -0,0 +1,94 @@
+package skald
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestAuthenticatedHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-api-key" {
+			t.Errorf("expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get(correlationIDHeader) == "" {
+			t.Error("expected correlation ID header")
+		}
+		if r.URL.Path != "/api/v1/memo" {
+			t.Errorf("expected path /api/v1/memo, got %s", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `{"count": 0, "results": []}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL)
+	WithCorrelationIDs()(client)
+
+	req, err := http.NewRequest("GET", server.URL+"/api/v1/memo", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := client.AuthenticatedHTTPClient().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("expected the caller's request not to be modified")
+	}
+}
+
+func TestAuthenticatedHTTPClientOtherHost(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("expected no Authorization header for another host, got %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer other.Close()
+
+	client := NewClient("test-api-key", "https://api.useskald.com")
+	resp, err := client.AuthenticatedHTTPClient().Get(other.URL + "/api/v1/memo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", resp.StatusCode)
+	}
+}
+
+func TestTargetsBaseURL(t *testing.T) {
+	client := NewClient("test-api-key", "https://skald.example.com/proxy/")
+
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"https://skald.example.com/proxy/api/v1/memo", true},
+		{"https://SKALD.example.com/proxy", true},
+		{"http://skald.example.com/proxy/api/v1/memo", false},
+		{"https://skald.example.com.evil.com/proxy/api/v1/memo", false},
+		{"https://skald.example.com/proxy-other/api", false},
+		{"https://skald.example.com/api/v1/memo", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("failed to parse URL: %v", err)
+			}
+			if got := client.targetsBaseURL(u); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
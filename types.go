@@ -1,7 +1,17 @@
 package skald
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -35,6 +45,14 @@ const (
 	FilterOperatorIn FilterOperator = "in"
 	// FilterOperatorNotIn matches if value is not in array
 	FilterOperatorNotIn FilterOperator = "not_in"
+	// FilterOperatorGt matches values greater than value
+	FilterOperatorGt FilterOperator = "gt"
+	// FilterOperatorGte matches values greater than or equal to value
+	FilterOperatorGte FilterOperator = "gte"
+	// FilterOperatorLt matches values less than value
+	FilterOperatorLt FilterOperator = "lt"
+	// FilterOperatorLte matches values less than or equal to value
+	FilterOperatorLte FilterOperator = "lte"
 )
 
 // FilterType specifies whether filter applies to native field or custom metadata
@@ -88,6 +106,9 @@ type RAGConfig struct {
 	VectorSearch *VectorSearchConfig `json:"vectorSearch,omitempty"`
 	Reranking    *RerankingConfig    `json:"reranking,omitempty"`
 	References   *ReferencesConfig   `json:"references,omitempty"`
+	// Seed makes the LLM's generation reproducible, e.g. in tests. It only has
+	// an effect with LLM providers that support seeded sampling.
+	Seed *int `json:"seed,omitempty"`
 }
 
 // MemoReference represents a reference to a memo in chat responses
@@ -99,6 +120,53 @@ type MemoReference struct {
 // References maps citation numbers to memo references
 type References map[string]MemoReference
 
+// MergeReferences combines several reference maps into a new map. References
+// are keyed by citation number; when sets share a number, the later set wins.
+// It returns nil if no set contains references.
+func MergeReferences(sets ...References) References {
+	var merged References
+	for _, set := range sets {
+		for number, ref := range set {
+			if merged == nil {
+				merged = make(References)
+			}
+			merged[number] = ref
+		}
+	}
+	return merged
+}
+
+// NumberedReference is a reference along with its citation number
+type NumberedReference struct {
+	Num int
+	Ref MemoReference
+}
+
+// Ordered returns the references ordered by citation number. References with
+// non-numeric keys are skipped.
+func (r References) Ordered() []NumberedReference {
+	ordered := make([]NumberedReference, 0, len(r))
+	for key, ref := range r {
+		number, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		ordered = append(ordered, NumberedReference{Num: number, Ref: ref})
+	}
+
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].Num < ordered[j].Num
+	})
+
+	return ordered
+}
+
+// Get returns the reference cited as [[n]], if any
+func (r References) Get(n int) (MemoReference, bool) {
+	ref, ok := r[strconv.Itoa(n)]
+	return ref, ok
+}
+
 // MemoData contains the data for creating a new memo
 type MemoData struct {
 	Title          string                 `json:"title"`
@@ -108,6 +176,92 @@ type MemoData struct {
 	Tags           []string               `json:"tags,omitempty"`
 	Source         *string                `json:"source,omitempty"`
 	ExpirationDate *time.Time             `json:"expiration_date,omitempty"`
+	// EmbeddingModel selects the embedding model used to index the memo
+	EmbeddingModel *string `json:"embedding_model,omitempty"`
+	// ChunkConfig controls how the memo is split into chunks
+	ChunkConfig *ChunkConfig `json:"chunk_config,omitempty"`
+}
+
+// ChunkStrategy selects where content is split into chunks
+type ChunkStrategy string
+
+const (
+	// ChunkStrategyFixed splits content by size alone
+	ChunkStrategyFixed ChunkStrategy = "fixed"
+	// ChunkStrategySentence splits content at sentence boundaries
+	ChunkStrategySentence ChunkStrategy = "sentence"
+	// ChunkStrategyParagraph splits content at paragraph boundaries
+	ChunkStrategyParagraph ChunkStrategy = "paragraph"
+)
+
+// ChunkConfig controls how a memo is split into chunks when it is indexed.
+// Unset fields are left to the server. PreviewChunks approximates the result.
+type ChunkConfig struct {
+	// Size is the maximum length of a chunk in characters
+	Size int `json:"size,omitempty"`
+	// Overlap is the number of characters each chunk repeats from the previous one
+	Overlap  *int          `json:"overlap,omitempty"`
+	Strategy ChunkStrategy `json:"strategy,omitempty"`
+}
+
+// MarshalJSON encodes the memo data, sending the expiration date and any times
+// in the metadata in UTC
+func (m MemoData) MarshalJSON() ([]byte, error) {
+	type memoData MemoData
+	m.ExpirationDate = utcTime(m.ExpirationDate)
+	m.Metadata = utcMetadata(m.Metadata)
+	return json.Marshal(memoData(m))
+}
+
+// utcTime returns a copy of t in UTC, as timestamps are sent to the API in UTC
+// regardless of the caller's time zone
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// utcMetadata returns a copy of metadata with all times converted to UTC, so
+// that the caller's map isn't modified
+func utcMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+	converted := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		converted[key] = utcValue(value)
+	}
+	return converted
+}
+
+// utcValue converts the times in value to UTC, including those nested in maps
+// and slices, which are copied. Other values are returned unchanged.
+func utcValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC()
+	case *time.Time:
+		if v != nil {
+			return utcTime(v)
+		}
+	case []time.Time:
+		converted := make([]time.Time, len(v))
+		for i, t := range v {
+			converted[i] = t.UTC()
+		}
+		return converted
+	case map[string]interface{}:
+		return utcMetadata(v)
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = utcValue(item)
+		}
+		return converted
+	}
+	return value
 }
 
 // CreateMemoResponse is the response from creating a memo
@@ -115,6 +269,20 @@ type CreateMemoResponse struct {
 	MemoUUID uuid.UUID `json:"memo_uuid"`
 }
 
+// GetMemoOptions contains the options for retrieving a memo
+type GetMemoOptions struct {
+	// Fields only retrieves the given fields (e.g. "title", "summary") to reduce
+	// the payload size. Fields that aren't selected are left as zero values.
+	Fields []string
+}
+
+// DeleteMemoOptions contains the options for deleting a memo
+type DeleteMemoOptions struct {
+	// Hard purges the memo and all its data immediately, even where the server
+	// would otherwise soft delete (archive) it, e.g. for compliance requests
+	Hard bool
+}
+
 // UpdateMemoData contains the fields that can be updated on a memo
 type UpdateMemoData struct {
 	Title             *string                `json:"title,omitempty"`
@@ -123,6 +291,23 @@ type UpdateMemoData struct {
 	ClientReferenceID *string                `json:"client_reference_id,omitempty"`
 	Source            *string                `json:"source,omitempty"`
 	ExpirationDate    *time.Time             `json:"expiration_date,omitempty"`
+	// IfMatch is sent as the If-Match header when set. The update fails with
+	// a 409 Conflict error if the memo's current version doesn't match.
+	IfMatch string `json:"-"`
+}
+
+// MarshalJSON encodes the update, sending the expiration date and any times in
+// the metadata in UTC
+func (u UpdateMemoData) MarshalJSON() ([]byte, error) {
+	type updateMemoData UpdateMemoData
+	u.ExpirationDate = utcTime(u.ExpirationDate)
+	u.Metadata = utcMetadata(u.Metadata)
+	return json.Marshal(updateMemoData(u))
+}
+
+// appendMemoContentRequest is the request payload for appending to a memo's content
+type appendMemoContentRequest struct {
+	Content string `json:"content"`
 }
 
 // UpdateMemoResponse is the response from updating a memo
@@ -143,6 +328,14 @@ type MemoChunk struct {
 	ChunkIndex   int    `json:"chunk_index"`
 }
 
+// listMemoChunksResponse is the paginated response from the memo chunks endpoint
+type listMemoChunksResponse struct {
+	Count    int         `json:"count"`
+	Next     *string     `json:"next"`
+	Previous *string     `json:"previous"`
+	Results  []MemoChunk `json:"results"`
+}
+
 // Memo represents a complete memo with all its data
 type Memo struct {
 	UUID              string                 `json:"uuid"`
@@ -161,6 +354,19 @@ type Memo struct {
 	Pending           bool                   `json:"pending"`
 	Tags              []MemoTag              `json:"tags"`
 	Chunks            []MemoChunk            `json:"chunks"`
+	// ETag identifies the version of the memo that was retrieved. Pass it as
+	// UpdateMemoData.IfMatch to only update the memo if it hasn't changed since.
+	ETag string `json:"-"`
+}
+
+// memoSummaryResponse is the response from the memo summary endpoint
+type memoSummaryResponse struct {
+	Summary string `json:"summary"`
+}
+
+// memoContentResponse is the response from the memo content range endpoint
+type memoContentResponse struct {
+	Content string `json:"content"`
 }
 
 // MemoListItem represents a memo in a list response
@@ -173,12 +379,48 @@ type MemoListItem struct {
 	ContentLength     int                    `json:"content_length"`
 	Metadata          map[string]interface{} `json:"metadata"`
 	ClientReferenceID *string                `json:"client_reference_id"`
+	// Status is the memo's processing status, empty if not reported by the API
+	Status MemoStatus `json:"status,omitempty"`
 }
 
 // ListMemosParams contains parameters for listing memos
 type ListMemosParams struct {
-	Page     *int `json:"page,omitempty"`
-	PageSize *int `json:"page_size,omitempty"`
+	Page     *int     `json:"page,omitempty"`
+	PageSize *int     `json:"page_size,omitempty"`
+	Filters  []Filter `json:"filters,omitempty"`
+	// Status only lists memos with the given processing status, e.g. to show
+	// the ingestion backlog
+	Status *MemoStatus `json:"status,omitempty"`
+	// Fields only retrieves the given fields of each memo (e.g. "uuid",
+	// "title") to reduce the payload size. Fields that aren't selected are
+	// left as zero values.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// addMemoTagsRequest is the request payload for adding tags to a memo
+type addMemoTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// TagMemosError is returned by TagMemosMatching when some of the matching memos
+// could not be tagged
+type TagMemosError struct {
+	// Failed maps the UUID of each memo that could not be tagged to its error
+	Failed map[string]error
+}
+
+func (e *TagMemosError) Error() string {
+	return fmt.Sprintf("failed to tag %d memos", len(e.Failed))
+}
+
+// Unwrap returns the errors of the failed memos, so that errors.Is and
+// errors.As match them
+func (e *TagMemosError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, err := range e.Failed {
+		errs = append(errs, err)
+	}
+	return errs
 }
 
 // ListMemosResponse is the response from listing memos
@@ -189,19 +431,229 @@ type ListMemosResponse struct {
 	Results  []MemoListItem `json:"results"`
 }
 
+// UsageInfo describes the account's plan, usage and limits. Limits are nil when
+// the plan doesn't have one.
+type UsageInfo struct {
+	Plan              string       `json:"plan"`
+	MemoCount         int          `json:"memo_count"`
+	MemoLimit         *int         `json:"memo_limit"`
+	StorageBytes      int64        `json:"storage_bytes"`
+	StorageLimitBytes *int64       `json:"storage_limit_bytes"`
+	Requests          RequestQuota `json:"requests"`
+}
+
+// RequestQuota describes the API request quota for the current period
+type RequestQuota struct {
+	Used     int        `json:"used"`
+	Limit    *int       `json:"limit"`
+	ResetsAt *time.Time `json:"resets_at"`
+}
+
+// ServerInfo describes the version of the Skald server and its supported features
+type ServerInfo struct {
+	Version  string   `json:"version"`
+	Features []string `json:"features"`
+}
+
+// Supports reports whether the server advertises feature
+func (s *ServerInfo) Supports(feature string) bool {
+	for _, f := range s.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook events that can be subscribed to with RegisterWebhook
+const (
+	// WebhookEventMemoProcessed is sent when a memo has been processed
+	WebhookEventMemoProcessed = "memo.processed"
+	// WebhookEventMemoError is sent when processing a memo failed
+	WebhookEventMemoError = "memo.error"
+)
+
+// Webhook is a URL subscribed to events
+type Webhook struct {
+	UUID      string    `json:"uuid"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+	// Secret signs the webhook's deliveries. It is only returned on registration.
+	Secret string `json:"secret,omitempty"`
+}
+
+// registerWebhookRequest is the request payload for registering a webhook
+type registerWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// MetadataField describes a custom metadata key present on at least one memo
+type MetadataField struct {
+	Key string `json:"key"`
+	// Type is the JSON type of the values stored under the key, e.g. "string"
+	Type string `json:"type"`
+	// MemoCount is the number of memos that have the key
+	MemoCount int `json:"memo_count"`
+}
+
+// metadataFieldsResponse is the response from the metadata fields endpoint
+type metadataFieldsResponse struct {
+	Fields []MetadataField `json:"fields"`
+}
+
+// UnknownMetadataKeys returns the keys targeted by custom metadata filters that
+// aren't among the given fields (as returned by DescribeMetadataFields). Such
+// filters match no memos, so they usually indicate a typo.
+func UnknownMetadataKeys(filters []Filter, fields []MetadataField) []string {
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.Key] = true
+	}
+
+	var unknown []string
+	for _, filter := range filters {
+		if filter.FilterType == FilterTypeCustomMetadata && !known[filter.Field] {
+			unknown = append(unknown, filter.Field)
+			known[filter.Field] = true // report each key once
+		}
+	}
+	return unknown
+}
+
 // Filter represents a filter condition for queries
 type Filter struct {
 	Field      string         `json:"field"`
 	Operator   FilterOperator `json:"operator"`
-	Value      interface{}    `json:"value"` // Can be string or []string
+	Value      interface{}    `json:"value"` // Can be string or []string, or time.Time for timestamp fields
 	FilterType FilterType     `json:"filter_type"`
 }
 
+// MarshalJSON encodes the filter, formatting time.Time and *time.Time values of
+// filters on the native timestamp fields (created_at and updated_at) as RFC3339
+// in UTC, including the elements of slices for "in" and "not_in". Other values,
+// such as date strings, are sent as is. Times in the values of filters on other
+// fields, such as metadata, are sent in UTC too.
+func (f Filter) MarshalJSON() ([]byte, error) {
+	if f.FilterType == FilterTypeNativeField && isTimestampField(f.Field) {
+		f.Value = timestampFilterValue(f.Value)
+	} else {
+		f.Value = utcValue(f.Value)
+	}
+
+	type filter Filter
+	return json.Marshal(filter(f))
+}
+
+// isTimestampField reports whether field is a native timestamp field
+func isTimestampField(field string) bool {
+	return field == "created_at" || field == "updated_at"
+}
+
+// timestampFilterValue formats the times in a timestamp filter value as RFC3339,
+// leaving values of other types unchanged
+func timestampFilterValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if v != nil {
+			return v.UTC().Format(time.RFC3339)
+		}
+	case []time.Time:
+		formatted := make([]string, len(v))
+		for i, t := range v {
+			formatted[i] = t.UTC().Format(time.RFC3339)
+		}
+		return formatted
+	case []*time.Time:
+		formatted := make([]interface{}, len(v))
+		for i, t := range v {
+			formatted[i] = timestampFilterValue(t)
+		}
+		return formatted
+	}
+	return value
+}
+
+// MergeFilters combines several sets of filters into one, preserving order and
+// dropping exact duplicates. Since filters are combined with AND logic, the
+// result matches only memos that satisfy every filter in every set.
+func MergeFilters(sets ...[]Filter) []Filter {
+	var merged []Filter
+	for _, set := range sets {
+		for _, filter := range set {
+			duplicate := false
+			for _, existing := range merged {
+				if reflect.DeepEqual(existing, filter) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				merged = append(merged, filter)
+			}
+		}
+	}
+	return merged
+}
+
+// SourceType names the source of a memo's content. Any string is a valid
+// source; the constants cover common integrations.
+type SourceType string
+
+const (
+	// SourceTypeNotion is content imported from Notion
+	SourceTypeNotion SourceType = "notion"
+	// SourceTypeConfluence is content imported from Confluence
+	SourceTypeConfluence SourceType = "confluence"
+	// SourceTypeSlack is content imported from Slack
+	SourceTypeSlack SourceType = "slack"
+	// SourceTypeUpload is content from an uploaded file
+	SourceTypeUpload SourceType = "upload"
+	// SourceTypeAPI is content created through the API
+	SourceTypeAPI SourceType = "api"
+)
+
+// IsKnown reports whether the source is one of the SourceType constants
+func (s SourceType) IsKnown() bool {
+	switch s {
+	case SourceTypeNotion, SourceTypeConfluence, SourceTypeSlack, SourceTypeUpload, SourceTypeAPI:
+		return true
+	}
+	return false
+}
+
+// Ptr returns the source as a *string, for use as MemoData.Source
+func (s SourceType) Ptr() *string {
+	source := string(s)
+	return &source
+}
+
+// SourceFilter returns a filter matching memos with the given source
+func SourceFilter(source SourceType) Filter {
+	return Filter{
+		Field:      "source",
+		Operator:   FilterOperatorEq,
+		Value:      string(source),
+		FilterType: FilterTypeNativeField,
+	}
+}
+
 // SearchRequest contains parameters for searching memos
 type SearchRequest struct {
 	Query   string   `json:"query"`
 	Limit   *int     `json:"limit,omitempty"`
 	Filters []Filter `json:"filters,omitempty"`
+	// MemoUUIDs restricts the search to the given memos
+	MemoUUIDs []string `json:"memo_uuids,omitempty"`
+	// Offset skips the given number of results, for paging through results
+	Offset *int `json:"offset,omitempty"`
+	// MinContentLength drops results whose content snippet has fewer
+	// characters, as very short chunks are often noise. Results are also
+	// filtered by the client in case the server doesn't support it.
+	MinContentLength *int `json:"min_content_length,omitempty"`
 }
 
 // SearchResult represents a single search result
@@ -219,6 +671,77 @@ type SearchResponse struct {
 	Results []SearchResult `json:"results"`
 }
 
+// SearchDiff describes how the memos of two search result sets differ, e.g. to
+// detect retrieval regressions when tuning the RAG config
+type SearchDiff struct {
+	// Added lists the memos only in the second set, in its order
+	Added []string
+	// Removed lists the memos only in the first set, in its order
+	Removed []string
+	// Reordered lists the memos in both sets whose rank among the memos common
+	// to both differs, in the order of the second set
+	Reordered []string
+}
+
+// Changed reports whether the result sets differ in their memos or their order
+func (d SearchDiff) Changed() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Reordered) > 0
+}
+
+// DiffSearchResults compares the memo UUIDs of two result sets. A memo is ranked
+// by its first result, so that several matching chunks of a memo count once.
+func DiffSearchResults(a, b []SearchResult) SearchDiff {
+	memosA := uniqueMemoUUIDs(a)
+	memosB := uniqueMemoUUIDs(b)
+
+	inA := make(map[string]bool, len(memosA))
+	for _, memoUUID := range memosA {
+		inA[memoUUID] = true
+	}
+	inB := make(map[string]bool, len(memosB))
+	for _, memoUUID := range memosB {
+		inB[memoUUID] = true
+	}
+
+	var diff SearchDiff
+	rankA := make(map[string]int)
+	for _, memoUUID := range memosA {
+		if !inB[memoUUID] {
+			diff.Removed = append(diff.Removed, memoUUID)
+			continue
+		}
+		rankA[memoUUID] = len(rankA)
+	}
+
+	rankB := 0
+	for _, memoUUID := range memosB {
+		if !inA[memoUUID] {
+			diff.Added = append(diff.Added, memoUUID)
+			continue
+		}
+		if rankA[memoUUID] != rankB {
+			diff.Reordered = append(diff.Reordered, memoUUID)
+		}
+		rankB++
+	}
+
+	return diff
+}
+
+// uniqueMemoUUIDs returns the memo UUIDs of results in order of first occurrence
+func uniqueMemoUUIDs(results []SearchResult) []string {
+	var memoUUIDs []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if seen[r.MemoUUID] {
+			continue
+		}
+		seen[r.MemoUUID] = true
+		memoUUIDs = append(memoUUIDs, r.MemoUUID)
+	}
+	return memoUUIDs
+}
+
 // ChatParams contains parameters for chat queries.
 // This is the public API struct that users pass to Chat() and StreamedChat() methods.
 type ChatParams struct {
@@ -227,17 +750,63 @@ type ChatParams struct {
 	SystemPrompt string     `json:"system_prompt,omitempty"`
 	ChatID       string     `json:"chat_id,omitempty"`
 	RAGConfig    *RAGConfig `json:"rag_config,omitempty"`
+	// MemoUUIDs restricts retrieval to the given memos
+	MemoUUIDs []string `json:"memo_uuids,omitempty"`
+	// DisableRetrieval makes the model answer directly without searching memos.
+	// Filters, MemoUUIDs and the retrieval parts of RAGConfig must not be set
+	// along with it, and default filters are not sent.
+	DisableRetrieval bool `json:"disable_retrieval,omitempty"`
+	// Language instructs the model to answer in the given language (e.g. "fr"),
+	// regardless of the language of the query or the memos
+	Language *string `json:"language,omitempty"`
+	// IncludeSteps controls whether the response contains the intermediate
+	// steps, which can be large. Set it to false to reduce the payload size;
+	// nil keeps the API's default.
+	IncludeSteps *bool `json:"include_steps,omitempty"`
+}
+
+// Validate returns an error wrapping ErrConflictingChatParams if the params
+// combine options whose meaning together is ambiguous: retrieval options
+// (Filters, MemoUUIDs or a retrieval RAGConfig) with DisableRetrieval, or
+// MemoUUIDs with a client_reference_id filter, which both select the memos to
+// retrieve from. Chat, StreamedChat and StreamRaw validate params before sending.
+func (p ChatParams) Validate() error {
+	if p.DisableRetrieval {
+		if len(p.Filters) > 0 {
+			return fmt.Errorf("%w: Filters are set but retrieval is disabled", ErrConflictingChatParams)
+		}
+		if len(p.MemoUUIDs) > 0 {
+			return fmt.Errorf("%w: MemoUUIDs are set but retrieval is disabled", ErrConflictingChatParams)
+		}
+		if rag := p.RAGConfig; rag != nil && (rag.QueryRewrite != nil || rag.VectorSearch != nil || rag.Reranking != nil || rag.References != nil) {
+			return fmt.Errorf("%w: RAGConfig configures retrieval but retrieval is disabled", ErrConflictingChatParams)
+		}
+	}
+
+	if len(p.MemoUUIDs) > 0 {
+		for _, filter := range p.Filters {
+			if filter.FilterType == FilterTypeNativeField && filter.Field == "client_reference_id" {
+				return fmt.Errorf("%w: MemoUUIDs and a client_reference_id filter both select memos", ErrConflictingChatParams)
+			}
+		}
+	}
+
+	return nil
 }
 
 // chatRequest is the internal HTTP request payload structure.
 // It includes the Stream field which is set automatically based on which method is called.
 type chatRequest struct {
-	Query        string     `json:"query"`
-	Stream       bool       `json:"stream"`
-	SystemPrompt string     `json:"system_prompt,omitempty"`
-	Filters      []Filter   `json:"filters,omitempty"`
-	ChatID       string     `json:"chat_id,omitempty"`
-	RAGConfig    *RAGConfig `json:"rag_config,omitempty"`
+	Query            string     `json:"query"`
+	Stream           bool       `json:"stream"`
+	SystemPrompt     string     `json:"system_prompt,omitempty"`
+	Filters          []Filter   `json:"filters,omitempty"`
+	ChatID           string     `json:"chat_id,omitempty"`
+	RAGConfig        *RAGConfig `json:"rag_config,omitempty"`
+	MemoUUIDs        []string   `json:"memo_uuids,omitempty"`
+	DisableRetrieval bool       `json:"disable_retrieval,omitempty"`
+	Language         *string    `json:"language,omitempty"`
+	IncludeSteps     *bool      `json:"include_steps,omitempty"`
 }
 
 // ChatResponse is the response from a non-streaming chat query
@@ -247,14 +816,121 @@ type ChatResponse struct {
 	IntermediateSteps []interface{} `json:"intermediate_steps"`
 	ChatID            string        `json:"chat_id,omitempty"`
 	References        References    `json:"references,omitempty"`
+	Usage             *TokenUsage   `json:"usage,omitempty"` // nil when the API omits usage
 }
 
-// ChatStreamEvent represents a streaming event from chat
+// TokenUsage reports the number of LLM tokens consumed by a chat query
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Footnote is a citation from a chat response, numbered as in the response text
+type Footnote struct {
+	Number int
+	Title  string
+	UUID   string
+}
+
+// Footnotes returns the response's references as footnotes ordered by citation number.
+// References with non-numeric keys are skipped.
+func (r *ChatResponse) Footnotes() []Footnote {
+	ordered := r.References.Ordered()
+	footnotes := make([]Footnote, 0, len(ordered))
+	for _, numbered := range ordered {
+		footnotes = append(footnotes, Footnote{
+			Number: numbered.Num,
+			Title:  numbered.Ref.MemoTitle,
+			UUID:   numbered.Ref.MemoUUID,
+		})
+	}
+
+	return footnotes
+}
+
+var (
+	// leadingCitationsPattern matches citation markers at the start of a line and the whitespace after them
+	leadingCitationsPattern = regexp.MustCompile(`(?m)^(?:\[\[\d+\]\])+[ \t]*`)
+	// citationsPattern matches citation markers and the whitespace before them
+	citationsPattern = regexp.MustCompile(`[ \t]*(?:\[\[\d+\]\])+`)
+)
+
+// PlainText returns the response text with [[n]] citation markers removed,
+// e.g. for text-to-speech. Whitespace around removed markers is collapsed so
+// that words and punctuation are spaced as if the markers were never there.
+func (r *ChatResponse) PlainText() string {
+	text := leadingCitationsPattern.ReplaceAllString(r.Response, "")
+	return citationsPattern.ReplaceAllString(text, "")
+}
+
+// citationPattern matches a single citation marker, capturing its number
+var citationPattern = regexp.MustCompile(`\[\[(\d+)\]\]`)
+
+// ToHTML returns the response text HTML-escaped, with each [[n]] citation marker
+// replaced by a superscript link to linkBase/<memo uuid> of the referenced memo.
+// Markers without a matching reference are kept as escaped text.
+func (r *ChatResponse) ToHTML(linkBase string) string {
+	linkBase = strings.TrimSuffix(linkBase, "/")
+
+	var b strings.Builder
+	last := 0
+	for _, match := range citationPattern.FindAllStringSubmatchIndex(r.Response, -1) {
+		number := r.Response[match[2]:match[3]]
+		ref, ok := r.References[number]
+		if !ok {
+			continue
+		}
+
+		b.WriteString(html.EscapeString(r.Response[last:match[0]]))
+		href := linkBase + "/" + url.PathEscape(ref.MemoUUID)
+		fmt.Fprintf(&b, `<sup><a href="%s">%s</a></sup>`, html.EscapeString(href), number)
+		last = match[1]
+	}
+	b.WriteString(html.EscapeString(r.Response[last:]))
+
+	return b.String()
+}
+
+// ChatStreamEvent represents a streaming event from chat.
+// Usage and Model are only populated on the final "done" event.
 type ChatStreamEvent struct {
-	Type       string     `json:"type"`
-	Content    *string    `json:"content,omitempty"`
-	ChatID     string     `json:"chat_id,omitempty"`
-	References References `json:"references,omitempty"`
+	Type       string      `json:"type"`
+	Content    *string     `json:"content,omitempty"`
+	ChatID     string      `json:"chat_id,omitempty"`
+	References References  `json:"references,omitempty"`
+	Usage      *TokenUsage `json:"usage,omitempty"`
+	Model      string      `json:"model,omitempty"`
+}
+
+// StreamPing is a ping (SSE comment line) received during a chat stream, as
+// reported to the handler set with WithStreamPingHandler
+type StreamPing struct {
+	// Comment is the text of the comment line, usually "ping"
+	Comment string
+	// ReceivedAt is when the ping was received
+	ReceivedAt time.Time
+	// Interval is the time since the previous ping, or since the stream
+	// started for the first ping
+	Interval time.Duration
+}
+
+// IndexedChatStreamEvent is an event from one of the chats run by StreamChats.
+// Index is the position of the chat in the list passed to StreamChats. If the
+// chat failed, Err is set and Event is empty.
+type IndexedChatStreamEvent struct {
+	Index int
+	Event ChatStreamEvent
+	Err   error
+}
+
+// ChatStreamResult is the accumulated result of a streaming chat query
+type ChatStreamResult struct {
+	Response   string
+	ChatID     string
+	References References
+	Usage      *TokenUsage
+	Model      string
 }
 
 // MemoStatus represents the processing status of a memo
@@ -269,6 +945,104 @@ const (
 	MemoStatusError MemoStatus = "error"
 )
 
+// MemoReadiness is a memo's processing state, reconciled from the Memo.Pending
+// flag and the status reported by CheckMemoStatus
+type MemoReadiness string
+
+const (
+	// MemoReadinessQueued indicates the memo is waiting for processing to start
+	MemoReadinessQueued MemoReadiness = "queued"
+	// MemoReadinessProcessing indicates the memo is being processed
+	MemoReadinessProcessing MemoReadiness = "processing"
+	// MemoReadinessReady indicates the memo has been processed and can be searched
+	MemoReadinessReady MemoReadiness = "ready"
+	// MemoReadinessError indicates the memo processing failed
+	MemoReadinessError MemoReadiness = "error"
+)
+
+// ReconcileReadiness combines the two signals the API reports about processing
+// into a single state. A memo is pending (Memo.Pending) until processing has
+// started, whereas the status (MemoStatusResponse.Status) reports "processing"
+// both before and during processing. Only the "processed" status means the
+// memo is ready: an empty or unknown status is treated as not processed yet,
+// and as queued if the memo is pending or the status is "pending".
+func ReconcileReadiness(pending bool, status MemoStatus) MemoReadiness {
+	switch status {
+	case MemoStatusError:
+		return MemoReadinessError
+	case MemoStatusProcessed:
+		return MemoReadinessReady
+	}
+
+	if pending || status == "pending" {
+		return MemoReadinessQueued
+	}
+	return MemoReadinessProcessing
+}
+
+// Readiness returns the memo's processing state based on its Pending flag
+func (m *Memo) Readiness() MemoReadiness {
+	if m.Pending {
+		return MemoReadinessQueued
+	}
+	return MemoReadinessReady
+}
+
+// Readiness returns the processing state reported by the status response
+func (s *MemoStatusResponse) Readiness() MemoReadiness {
+	return ReconcileReadiness(false, s.Status)
+}
+
+// ProcessingDuration returns how long processing took, from DurationMs or else
+// from StartedAt and CompletedAt. It returns false if the API reported neither.
+func (s *MemoStatusResponse) ProcessingDuration() (time.Duration, bool) {
+	if s.DurationMs != nil {
+		return time.Duration(*s.DurationMs) * time.Millisecond, true
+	}
+	if s.StartedAt != nil && s.CompletedAt != nil {
+		return s.CompletedAt.Sub(*s.StartedAt), true
+	}
+	return 0, false
+}
+
+// EqualContent reports whether two memos have the same title, content, metadata,
+// tags and source, ignoring server-managed fields such as the UUID, timestamps,
+// summary and chunks. Tags are compared regardless of order, and nil and empty
+// metadata are considered equal.
+func (m *Memo) EqualContent(other *Memo) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+	if m.Title != other.Title || m.Content != other.Content {
+		return false
+	}
+	if (m.Source == nil) != (other.Source == nil) || (m.Source != nil && *m.Source != *other.Source) {
+		return false
+	}
+	if len(m.Metadata) != len(other.Metadata) || (len(m.Metadata) > 0 && !reflect.DeepEqual(m.Metadata, other.Metadata)) {
+		return false
+	}
+	return equalTags(m.Tags, other.Tags)
+}
+
+// equalTags reports whether two tag lists contain the same tags in any order
+func equalTags(a, b []MemoTag) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, tag := range a {
+		counts[tag.Tag]++
+	}
+	for _, tag := range b {
+		if counts[tag.Tag] == 0 {
+			return false
+		}
+		counts[tag.Tag]--
+	}
+	return true
+}
+
 // MemoFileData contains the data for creating a memo from a file
 type MemoFileData struct {
 	Title          *string                `json:"title,omitempty"`
@@ -277,22 +1051,155 @@ type MemoFileData struct {
 	Tags           []string               `json:"tags,omitempty"`
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 	ExpirationDate *time.Time             `json:"expiration_date,omitempty"`
+	// EmbeddingModel selects the embedding model used to index the memo
+	EmbeddingModel *string `json:"embedding_model,omitempty"`
+	// ChunkConfig controls how the memo is split into chunks
+	ChunkConfig *ChunkConfig `json:"chunk_config,omitempty"`
+}
+
+// createMemoFromURLRequest is the request payload for creating a memo from a URL
+type createMemoFromURLRequest struct {
+	URL string `json:"url"`
+	*MemoFileData
+}
+
+// MarshalJSON encodes the request, sending the expiration date and any times in
+// the metadata in UTC
+func (r createMemoFromURLRequest) MarshalJSON() ([]byte, error) {
+	type request createMemoFromURLRequest
+	if r.MemoFileData != nil {
+		memoData := *r.MemoFileData
+		memoData.ExpirationDate = utcTime(memoData.ExpirationDate)
+		memoData.Metadata = utcMetadata(memoData.Metadata)
+		r.MemoFileData = &memoData
+	}
+	return json.Marshal(request(r))
 }
 
 // MemoStatusResponse represents the response from checking memo status
 type MemoStatusResponse struct {
 	Status      MemoStatus `json:"status"`
 	ErrorReason *string    `json:"error_reason,omitempty"`
+	// Progress is the processing progress as a percentage (0-100), when reported by the API
+	Progress *float64 `json:"progress,omitempty"`
+	// StartedAt, CompletedAt and DurationMs time the processing of the memo,
+	// when reported by the API, e.g. to track ingestion performance
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	DurationMs  *int64     `json:"duration_ms,omitempty"`
+}
+
+// ErrResponseTooLarge is returned when a response body exceeds the size
+// configured with WithMaxResponseSize
+var ErrResponseTooLarge = errors.New("skald: response body exceeds maximum size")
+
+// ErrMemoAlreadyProcessed is returned when cancelling the processing of a memo
+// that has already finished processing
+var ErrMemoAlreadyProcessed = errors.New("skald: memo has already been processed")
+
+// ErrNotifierUnavailable is returned by a MemoNotifier that can't currently
+// deliver notifications, so that AwaitMemoProcessed falls back to polling
+var ErrNotifierUnavailable = errors.New("skald: memo notifier unavailable")
+
+// MemoNotifier is notified when memos finish processing, e.g. by a webhook
+// receiver, so that AwaitMemoProcessed doesn't have to poll
+type MemoNotifier interface {
+	// WaitForMemo blocks until the memo with the given UUID has finished
+	// processing, successfully or not, and returns its final status
+	WaitForMemo(ctx context.Context, memoID string) (*MemoStatusResponse, error)
+}
+
+// ErrInvalidMetadata is returned by CreateMemo and UpdateMemo when metadata
+// doesn't match the schema set with WithMetadataSchema
+var ErrInvalidMetadata = errors.New("skald: metadata does not match schema")
+
+// ErrConflictingChatParams is returned by ChatParams.Validate, and thus before
+// sending a chat query, when the params combine conflicting options
+var ErrConflictingChatParams = errors.New("skald: conflicting chat params")
+
+// ErrInvalidAPIKey is returned when an API key doesn't have the expected format
+var ErrInvalidAPIKey = errors.New("skald: invalid API key format")
+
+// ErrNoSearchResults is returned by SearchAndChat when the search matches no
+// memos to scope the chat to
+var ErrNoSearchResults = errors.New("skald: search returned no results")
+
+// ErrSearchTruncated is returned by SearchAll, along with the results up to the
+// cap, when the search has more results than the cap allows
+var ErrSearchTruncated = errors.New("skald: search results truncated")
+
+// SearchAndChatResult is the result of SearchAndChat
+type SearchAndChatResult struct {
+	// Search is the response of the search that selected the memos
+	Search *SearchResponse
+	// MemoUUIDs are the memos the chat was scoped to, in search rank order
+	MemoUUIDs []string
+	// Chat is the answer generated from those memos
+	Chat *ChatResponse
+}
+
+// BatchOperation names the operation performed by a BatchOp
+type BatchOperation string
+
+const (
+	// BatchOperationCreate creates a memo from BatchOp.Memo
+	BatchOperationCreate BatchOperation = "create"
+	// BatchOperationUpdate updates BatchOp.MemoID with BatchOp.Update
+	BatchOperationUpdate BatchOperation = "update"
+	// BatchOperationDelete deletes BatchOp.MemoID
+	BatchOperationDelete BatchOperation = "delete"
+)
+
+// BatchOp is a single operation in a batch request
+type BatchOp struct {
+	Operation BatchOperation  `json:"operation"`
+	MemoID    string          `json:"memo_id,omitempty"`
+	IDType    IDType          `json:"id_type,omitempty"`
+	Memo      *MemoData       `json:"memo,omitempty"`
+	Update    *UpdateMemoData `json:"update,omitempty"`
+}
+
+// BatchResult is the outcome of a single operation in a batch request
+type BatchResult struct {
+	// MemoUUID is the UUID of the created or updated memo
+	MemoUUID string
+	// Err is the error of the operation, or nil if it succeeded
+	Err error
+}
+
+// batchRequest is the request payload of the batch endpoint
+type batchRequest struct {
+	Operations []BatchOp `json:"operations"`
+}
+
+// batchResponse is the response from the batch endpoint
+type batchResponse struct {
+	Results []struct {
+		// Index is the position of the operation in the request, if the
+		// server reports it; otherwise results are in request order
+		Index      *int   `json:"index"`
+		MemoUUID   string `json:"memo_uuid"`
+		StatusCode int    `json:"status_code"`
+		Error      string `json:"error"`
+	} `json:"results"`
 }
 
 // APIError represents an error returned by the Skald API
 type APIError struct {
 	StatusCode int
 	Message    string
+	// Code is the machine-readable error code from the response body, if any
+	// (e.g. "invalid_filter"). It is empty when the API doesn't provide one.
+	Code string
+	// CorrelationID is the X-Correlation-ID sent with the failed request, if any
+	CorrelationID string
 }
 
 // Error implements the error interface
 func (e *APIError) Error() string {
+	if e.CorrelationID != "" {
+		return fmt.Sprintf("skald API error (%d): %s (correlation ID: %s)", e.StatusCode, e.Message, e.CorrelationID)
+	}
 	return fmt.Sprintf("skald API error (%d): %s", e.StatusCode, e.Message)
 }
 
@@ -310,3 +1217,57 @@ func (e *APIError) IsUnauthorized() bool {
 func (e *APIError) IsBadRequest() bool {
 	return e.StatusCode == 400
 }
+
+// IsPayloadTooLarge returns true if the error is a 413 Payload Too Large error
+func (e *APIError) IsPayloadTooLarge() bool {
+	return e.StatusCode == 413
+}
+
+// IsConflict returns true if the error is a 409 Conflict error
+func (e *APIError) IsConflict() bool {
+	return e.StatusCode == 409
+}
+
+// IsForbidden returns true if the error is a 403 Forbidden error
+func (e *APIError) IsForbidden() bool {
+	return e.StatusCode == 403
+}
+
+// IsServiceUnavailable returns true if the error is a 503 Service Unavailable
+// error, which the API returns while in maintenance mode
+func (e *APIError) IsServiceUnavailable() bool {
+	return e.StatusCode == 503
+}
+
+// IsQuotaExceeded returns true if the error indicates that a plan limit was reached,
+// either as a 402 Payment Required error or a 403 error with a "quota_exceeded" code
+func (e *APIError) IsQuotaExceeded() bool {
+	if e.StatusCode == 402 {
+		return true
+	}
+	return e.StatusCode == 403 && e.Code == "quota_exceeded"
+}
+
+// DuplicateReferenceIDError is returned when creating a memo fails with a 409
+// Conflict because a memo with the same reference ID already exists, so that
+// callers can update that memo instead. It unwraps to the underlying *APIError.
+type DuplicateReferenceIDError struct {
+	// ReferenceID is the reference ID that is already in use
+	ReferenceID string
+	Err         *APIError
+}
+
+// Error implements the error interface
+func (e *DuplicateReferenceIDError) Error() string {
+	return fmt.Sprintf("memo with reference ID %q already exists: %v", e.ReferenceID, e.Err)
+}
+
+// Unwrap returns the underlying API error
+func (e *DuplicateReferenceIDError) Unwrap() error {
+	return e.Err
+}
+
+// IsConflict always returns true, as a duplicate reference ID is a 409 Conflict error
+func (e *DuplicateReferenceIDError) IsConflict() bool {
+	return true
+}
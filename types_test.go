@@ -0,0 +1,624 @@
+package skald
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatResponseFootnotes(t *testing.T) {
+	resp := &ChatResponse{
+		Response: "See [[10]], [[2]] and [[1]]",
+		References: References{
+			"10":  {MemoUUID: "uuid-10", MemoTitle: "Tenth"},
+			"2":   {MemoUUID: "uuid-2", MemoTitle: "Second"},
+			"1":   {MemoUUID: "uuid-1", MemoTitle: "First"},
+			"abc": {MemoUUID: "uuid-x", MemoTitle: "Invalid"},
+		},
+	}
+
+	footnotes := resp.Footnotes()
+
+	expected := []Footnote{
+		{Number: 1, Title: "First", UUID: "uuid-1"},
+		{Number: 2, Title: "Second", UUID: "uuid-2"},
+		{Number: 10, Title: "Tenth", UUID: "uuid-10"},
+	}
+	if len(footnotes) != len(expected) {
+		t.Fatalf("expected %d footnotes, got %d: %+v", len(expected), len(footnotes), footnotes)
+	}
+	for i := range expected {
+		if footnotes[i] != expected[i] {
+			t.Errorf("expected footnote %d to be %+v, got %+v", i, expected[i], footnotes[i])
+		}
+	}
+}
+
+func TestChatResponseFootnotesEmpty(t *testing.T) {
+	resp := &ChatResponse{Response: "No citations"}
+	if footnotes := resp.Footnotes(); len(footnotes) != 0 {
+		t.Errorf("expected no footnotes, got %+v", footnotes)
+	}
+}
+
+func TestMergeFilters(t *testing.T) {
+	tenant := Filter{Field: "tenant", Operator: FilterOperatorEq, Value: "acme", FilterType: FilterTypeCustomMetadata}
+	tags := Filter{Field: "tags", Operator: FilterOperatorIn, Value: []string{"a", "b"}, FilterType: FilterTypeNativeField}
+	source := Filter{Field: "source", Operator: FilterOperatorEq, Value: "notion", FilterType: FilterTypeNativeField}
+
+	merged := MergeFilters([]Filter{tenant}, []Filter{tags, tenant}, nil, []Filter{source, tags})
+
+	expected := []Filter{tenant, tags, source}
+	if len(merged) != len(expected) {
+		t.Fatalf("expected %d filters, got %d: %+v", len(expected), len(merged), merged)
+	}
+	for i := range expected {
+		if merged[i].Field != expected[i].Field {
+			t.Errorf("expected filter %d on %q, got %q", i, expected[i].Field, merged[i].Field)
+		}
+	}
+
+	if merged := MergeFilters(); merged != nil {
+		t.Errorf("expected nil for no filters, got %+v", merged)
+	}
+}
+
+func TestChatResponsePlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected string
+	}{
+		{
+			name:     "no markers",
+			response: "Plain answer.",
+			expected: "Plain answer.",
+		},
+		{
+			name:     "marker before period",
+			response: "Paris is the capital of France [[1]].",
+			expected: "Paris is the capital of France.",
+		},
+		{
+			name:     "marker after period",
+			response: "Paris is the capital.[[1]] It is large.",
+			expected: "Paris is the capital. It is large.",
+		},
+		{
+			name:     "adjacent markers mid-sentence",
+			response: "Revenue targets [[1]][[2]] and hiring plans [[3]] were discussed.",
+			expected: "Revenue targets and hiring plans were discussed.",
+		},
+		{
+			name:     "marker without space",
+			response: "Revenue grew[[1]], costs fell[[2]].",
+			expected: "Revenue grew, costs fell.",
+		},
+		{
+			name:     "marker at start of lines",
+			response: "[[1]] First point\n[[2]][[3]] Second point",
+			expected: "First point\nSecond point",
+		},
+		{
+			name:     "marker at end",
+			response: "The answer is 42 [[10]]",
+			expected: "The answer is 42",
+		},
+		{
+			name:     "list items",
+			response: "1. Revenue targets [[1]]\n2. Product roadmap [[1]][[3]]",
+			expected: "1. Revenue targets\n2. Product roadmap",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &ChatResponse{Response: tt.response}
+			if got := resp.PlainText(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReconcileReadiness(t *testing.T) {
+	tests := []struct {
+		pending  bool
+		status   MemoStatus
+		expected MemoReadiness
+	}{
+		{pending: true, status: MemoStatusProcessing, expected: MemoReadinessQueued},
+		{pending: false, status: MemoStatusProcessing, expected: MemoReadinessProcessing},
+		{pending: false, status: MemoStatusProcessed, expected: MemoReadinessReady},
+		{pending: true, status: MemoStatusProcessed, expected: MemoReadinessReady},
+		{pending: false, status: MemoStatusError, expected: MemoReadinessError},
+		{pending: true, status: MemoStatusError, expected: MemoReadinessError},
+		{pending: true, status: "", expected: MemoReadinessQueued},
+		{pending: false, status: "", expected: MemoReadinessProcessing},
+		{pending: false, status: "pending", expected: MemoReadinessQueued},
+		{pending: false, status: "archived", expected: MemoReadinessProcessing},
+	}
+
+	for _, tt := range tests {
+		if got := ReconcileReadiness(tt.pending, tt.status); got != tt.expected {
+			t.Errorf("pending=%v status=%q: expected %s, got %s", tt.pending, tt.status, tt.expected, got)
+		}
+	}
+}
+
+func TestReadinessMethods(t *testing.T) {
+	if got := (&Memo{Pending: true}).Readiness(); got != MemoReadinessQueued {
+		t.Errorf("expected pending memo to be queued, got %s", got)
+	}
+	if got := (&Memo{Pending: false}).Readiness(); got != MemoReadinessReady {
+		t.Errorf("expected non-pending memo to be ready, got %s", got)
+	}
+	if got := (&MemoStatusResponse{Status: MemoStatusProcessing}).Readiness(); got != MemoReadinessProcessing {
+		t.Errorf("expected processing status to be processing, got %s", got)
+	}
+	if got := (&MemoStatusResponse{Status: MemoStatusError}).Readiness(); got != MemoReadinessError {
+		t.Errorf("expected error status to be error, got %s", got)
+	}
+}
+
+func TestSourceType(t *testing.T) {
+	for _, source := range []SourceType{SourceTypeNotion, SourceTypeConfluence, SourceTypeSlack, SourceTypeUpload, SourceTypeAPI} {
+		if !source.IsKnown() {
+			t.Errorf("expected %q to be known", source)
+		}
+	}
+	if SourceTypeNotion != "notion" || SourceTypeAPI != "api" {
+		t.Error("unexpected source constant values")
+	}
+
+	custom := SourceType("zendesk")
+	if custom.IsKnown() {
+		t.Error("expected custom source not to be known")
+	}
+	if ptr := custom.Ptr(); ptr == nil || *ptr != "zendesk" {
+		t.Errorf("expected pointer to zendesk, got %v", ptr)
+	}
+
+	filter := SourceFilter(custom)
+	expected := Filter{Field: "source", Operator: FilterOperatorEq, Value: "zendesk", FilterType: FilterTypeNativeField}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("expected %+v, got %+v", expected, filter)
+	}
+
+	body, err := json.Marshal(SourceFilter(SourceTypeSlack))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(body) != `{"field":"source","operator":"eq","value":"slack","filter_type":"native_field"}` {
+		t.Errorf("unexpected filter JSON: %s", body)
+	}
+}
+
+func TestMergeReferences(t *testing.T) {
+	merged := MergeReferences(
+		References{"1": {MemoUUID: "uuid-1", MemoTitle: "First"}},
+		nil,
+		References{"2": {MemoUUID: "uuid-2"}, "1": {MemoUUID: "uuid-1", MemoTitle: "Updated"}},
+	)
+
+	expected := References{
+		"1": {MemoUUID: "uuid-1", MemoTitle: "Updated"},
+		"2": {MemoUUID: "uuid-2"},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %+v, got %+v", expected, merged)
+	}
+
+	if MergeReferences(nil, References{}) != nil {
+		t.Error("expected nil when there are no references")
+	}
+}
+
+func TestTimestampFilters(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 31, 23, 59, 59, 0, time.FixedZone("CET", 3600))
+
+	body, err := json.Marshal([]Filter{
+		{Field: "created_at", Operator: FilterOperatorGte, Value: start, FilterType: FilterTypeNativeField},
+		{Field: "created_at", Operator: FilterOperatorLte, Value: &end, FilterType: FilterTypeNativeField},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `[{"field":"created_at","operator":"gte","value":"2026-01-01T00:00:00Z","filter_type":"native_field"},` +
+		`{"field":"created_at","operator":"lte","value":"2026-03-31T22:59:59Z","filter_type":"native_field"}]`
+	if string(body) != expected {
+		t.Errorf("expected %s, got %s", expected, body)
+	}
+}
+
+func TestTimestampFilterValues(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 31, 23, 59, 59, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected string
+	}{
+		{
+			name:     "RFC3339 string",
+			filter:   Filter{Field: "updated_at", Operator: FilterOperatorGt, Value: "2026-01-01T00:00:00Z", FilterType: FilterTypeNativeField},
+			expected: `"2026-01-01T00:00:00Z"`,
+		},
+		{
+			name:     "date string",
+			filter:   Filter{Field: "updated_at", Operator: FilterOperatorGt, Value: "2024-01-01", FilterType: FilterTypeNativeField},
+			expected: `"2024-01-01"`,
+		},
+		{
+			name:     "number",
+			filter:   Filter{Field: "created_at", Operator: FilterOperatorGt, Value: 1767225600, FilterType: FilterTypeNativeField},
+			expected: `1767225600`,
+		},
+		{
+			name:     "nil time",
+			filter:   Filter{Field: "created_at", Operator: FilterOperatorGt, Value: (*time.Time)(nil), FilterType: FilterTypeNativeField},
+			expected: `null`,
+		},
+		{
+			name:     "string slice",
+			filter:   Filter{Field: "created_at", Operator: FilterOperatorIn, Value: []string{"2024-01-01", "2024-01-02"}, FilterType: FilterTypeNativeField},
+			expected: `["2024-01-01","2024-01-02"]`,
+		},
+		{
+			name:     "time slice",
+			filter:   Filter{Field: "created_at", Operator: FilterOperatorNotIn, Value: []time.Time{start, end}, FilterType: FilterTypeNativeField},
+			expected: `["2026-01-01T00:00:00Z","2026-03-31T22:59:59Z"]`,
+		},
+		{
+			name:     "time pointer slice",
+			filter:   Filter{Field: "created_at", Operator: FilterOperatorIn, Value: []*time.Time{&end, nil}, FilterType: FilterTypeNativeField},
+			expected: `["2026-03-31T22:59:59Z",null]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(body), `"value":`+tt.expected+`,`) {
+				t.Errorf("expected value %s, got %s", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestMemoEqualContent(t *testing.T) {
+	source := "notion"
+	otherSource := "slack"
+	base := func() *Memo {
+		src := source
+		return &Memo{
+			UUID:     "123e4567-e89b-12d3-a456-426614174000",
+			Title:    "Title",
+			Content:  "Content",
+			Summary:  "Summary",
+			Metadata: map[string]interface{}{"author": "jane"},
+			Source:   &src,
+			Tags:     []MemoTag{{UUID: "1", Tag: "a"}, {UUID: "2", Tag: "b"}},
+			Chunks:   []MemoChunk{{UUID: "c1"}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(m *Memo)
+		want   bool
+	}{
+		{"identical", func(m *Memo) {}, true},
+		{"server-managed fields ignored", func(m *Memo) {
+			m.UUID = "other"
+			m.CreatedAt = time.Now()
+			m.UpdatedAt = time.Now()
+			m.Summary = "other"
+			m.Chunks = nil
+		}, true},
+		{"tags reordered", func(m *Memo) {
+			m.Tags = []MemoTag{{UUID: "3", Tag: "b"}, {UUID: "4", Tag: "a"}}
+		}, true},
+		{"title differs", func(m *Memo) { m.Title = "Other" }, false},
+		{"content differs", func(m *Memo) { m.Content = "Other" }, false},
+		{"metadata differs", func(m *Memo) { m.Metadata = map[string]interface{}{"author": "john"} }, false},
+		{"metadata missing", func(m *Memo) { m.Metadata = nil }, false},
+		{"tag differs", func(m *Memo) { m.Tags = []MemoTag{{Tag: "a"}, {Tag: "c"}} }, false},
+		{"tag missing", func(m *Memo) { m.Tags = m.Tags[:1] }, false},
+		{"source differs", func(m *Memo) { m.Source = &otherSource }, false},
+		{"source missing", func(m *Memo) { m.Source = nil }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := base()
+			tt.modify(other)
+			if got := base().EqualContent(other); got != tt.want {
+				t.Errorf("EqualContent() = %v, want %v", got, tt.want)
+			}
+			if got := other.EqualContent(base()); got != tt.want {
+				t.Errorf("EqualContent() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("nil and empty metadata equal", func(t *testing.T) {
+		a := &Memo{Title: "Title"}
+		b := &Memo{Title: "Title", Metadata: map[string]interface{}{}}
+		if !a.EqualContent(b) {
+			t.Error("expected nil and empty metadata to be equal")
+		}
+	})
+}
+
+func TestUnknownMetadataKeys(t *testing.T) {
+	fields := []MetadataField{{Key: "author"}, {Key: "year"}}
+	filters := []Filter{
+		{Field: "author", Operator: FilterOperatorEq, Value: "jane", FilterType: FilterTypeCustomMetadata},
+		{Field: "autor", Operator: FilterOperatorEq, Value: "jane", FilterType: FilterTypeCustomMetadata},
+		{Field: "autor", Operator: FilterOperatorNeq, Value: "john", FilterType: FilterTypeCustomMetadata},
+		{Field: "source", Operator: FilterOperatorEq, Value: "notion", FilterType: FilterTypeNativeField},
+	}
+
+	unknown := UnknownMetadataKeys(filters, fields)
+	if !reflect.DeepEqual(unknown, []string{"autor"}) {
+		t.Errorf("expected [autor], got %v", unknown)
+	}
+
+	if unknown := UnknownMetadataKeys(filters[:1], fields); unknown != nil {
+		t.Errorf("expected no unknown keys, got %v", unknown)
+	}
+}
+
+func TestChatResponseToHTML(t *testing.T) {
+	refs := References{
+		"1": {MemoUUID: "uuid-1", MemoTitle: "Q1 Meeting"},
+		"2": {MemoUUID: "uuid-2", MemoTitle: "Roadmap"},
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		linkBase string
+		expected string
+	}{
+		{
+			name:     "multiple citations",
+			response: "Revenue grew[[1]][[2]] and costs fell[[2]].",
+			linkBase: "https://app.example.com/memos",
+			expected: `Revenue grew<sup><a href="https://app.example.com/memos/uuid-1">1</a></sup>` +
+				`<sup><a href="https://app.example.com/memos/uuid-2">2</a></sup>` +
+				` and costs fell<sup><a href="https://app.example.com/memos/uuid-2">2</a></sup>.`,
+		},
+		{
+			name:     "trailing slash on link base",
+			response: "See[[1]]",
+			linkBase: "/memos/",
+			expected: `See<sup><a href="/memos/uuid-1">1</a></sup>`,
+		},
+		{
+			name:     "surrounding text escaped",
+			response: `Use <script>alert("x")</script> & "quotes"[[1]] <b>`,
+			linkBase: "/memos",
+			expected: `Use &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; &#34;quotes&#34;` +
+				`<sup><a href="/memos/uuid-1">1</a></sup> &lt;b&gt;`,
+		},
+		{
+			name:     "unknown reference kept as text",
+			response: "Unsourced[[9]] claim",
+			linkBase: "/memos",
+			expected: "Unsourced[[9]] claim",
+		},
+		{
+			name:     "no citations",
+			response: "Plain answer",
+			linkBase: "/memos",
+			expected: "Plain answer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ChatResponse{Response: tt.response, References: refs}
+			if got := r.ToHTML(tt.linkBase); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReferencesOrdered(t *testing.T) {
+	refs := References{
+		"10": {MemoUUID: "uuid-10"},
+		"2":  {MemoUUID: "uuid-2"},
+		"7":  {MemoUUID: "uuid-7"},
+		"x":  {MemoUUID: "uuid-x"},
+	}
+
+	ordered := refs.Ordered()
+	expected := []NumberedReference{
+		{Num: 2, Ref: MemoReference{MemoUUID: "uuid-2"}},
+		{Num: 7, Ref: MemoReference{MemoUUID: "uuid-7"}},
+		{Num: 10, Ref: MemoReference{MemoUUID: "uuid-10"}},
+	}
+	if !reflect.DeepEqual(ordered, expected) {
+		t.Errorf("expected %+v, got %+v", expected, ordered)
+	}
+
+	if ordered := References(nil).Ordered(); len(ordered) != 0 {
+		t.Errorf("expected no references, got %+v", ordered)
+	}
+}
+
+func TestReferencesGet(t *testing.T) {
+	refs := References{
+		"10": {MemoUUID: "uuid-10"},
+		"2":  {MemoUUID: "uuid-2"},
+	}
+
+	if ref, ok := refs.Get(10); !ok || ref.MemoUUID != "uuid-10" {
+		t.Errorf("expected uuid-10, got %+v (found: %v)", ref, ok)
+	}
+	if ref, ok := refs.Get(2); !ok || ref.MemoUUID != "uuid-2" {
+		t.Errorf("expected uuid-2, got %+v (found: %v)", ref, ok)
+	}
+	if _, ok := refs.Get(3); ok {
+		t.Error("expected no reference for 3")
+	}
+}
+
+func TestOutgoingTimesUTC(t *testing.T) {
+	local := time.Date(2026, 6, 1, 9, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	expected := `"expiration_date":"2026-06-01T14:30:00Z"`
+
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "MemoData", value: MemoData{Title: "Title", ExpirationDate: &local}},
+		{name: "*MemoData", value: &MemoData{Title: "Title", ExpirationDate: &local}},
+		{name: "UpdateMemoData", value: UpdateMemoData{ExpirationDate: &local}},
+		{name: "createMemoFromURLRequest", value: createMemoFromURLRequest{
+			URL:          "https://example.com/doc.pdf",
+			MemoFileData: &MemoFileData{ExpirationDate: &local},
+		}},
+		{name: "BatchOp", value: BatchOp{Memo: &MemoData{Title: "Title", ExpirationDate: &local}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(body), expected) {
+				t.Errorf("expected %s in %s", expected, body)
+			}
+		})
+	}
+
+	metadata := map[string]interface{}{"due": local, "nested": map[string]interface{}{"at": []interface{}{&local}}}
+	metadataTests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "MemoData metadata", value: MemoData{Title: "Title", Metadata: metadata}},
+		{name: "UpdateMemoData metadata", value: UpdateMemoData{Metadata: metadata}},
+		{name: "createMemoFromURLRequest metadata", value: createMemoFromURLRequest{
+			URL:          "https://example.com/doc.pdf",
+			MemoFileData: &MemoFileData{Metadata: metadata},
+		}},
+		{name: "metadata filter", value: Filter{Field: "due", Operator: FilterOperatorEq, Value: local, FilterType: FilterTypeCustomMetadata}},
+	}
+
+	for _, tt := range metadataTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(body), `"2026-06-01T14:30:00Z"`) || strings.Contains(string(body), "-05:00") {
+				t.Errorf("expected all times in UTC, got %s", body)
+			}
+		})
+	}
+
+	t.Run("caller's metadata unchanged", func(t *testing.T) {
+		if _, err := json.Marshal(MemoData{Metadata: metadata}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if due := metadata["due"].(time.Time); due.Location().String() != "EST" {
+			t.Errorf("expected caller's metadata to keep its location, got %s", due.Location())
+		}
+	})
+
+	t.Run("caller's time unchanged", func(t *testing.T) {
+		if _, err := json.Marshal(MemoData{ExpirationDate: &local}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if local.Location().String() != "EST" {
+			t.Errorf("expected caller's time to keep its location, got %s", local.Location())
+		}
+	})
+
+	t.Run("URL request keeps URL", func(t *testing.T) {
+		body, err := json.Marshal(createMemoFromURLRequest{URL: "https://example.com/doc.pdf"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(body) != `{"url":"https://example.com/doc.pdf"}` {
+			t.Errorf("unexpected body %s", body)
+		}
+	})
+}
+
+func TestDiffSearchResults(t *testing.T) {
+	results := func(memoUUIDs ...string) []SearchResult {
+		var rs []SearchResult
+		for _, memoUUID := range memoUUIDs {
+			rs = append(rs, SearchResult{MemoUUID: memoUUID})
+		}
+		return rs
+	}
+
+	tests := []struct {
+		name     string
+		a, b     []SearchResult
+		expected SearchDiff
+	}{
+		{
+			name: "identical",
+			a:    results("m1", "m2", "m3"),
+			b:    results("m1", "m2", "m3"),
+		},
+		{
+			name: "repeated chunks of a memo count once",
+			a:    results("m1", "m1", "m2"),
+			b:    results("m1", "m2", "m2"),
+		},
+		{
+			name:     "reordered",
+			a:        results("m1", "m2", "m3"),
+			b:        results("m2", "m1", "m3"),
+			expected: SearchDiff{Reordered: []string{"m2", "m1"}},
+		},
+		{
+			name:     "disjoint",
+			a:        results("m1", "m2"),
+			b:        results("m3", "m4"),
+			expected: SearchDiff{Added: []string{"m3", "m4"}, Removed: []string{"m1", "m2"}},
+		},
+		{
+			name:     "added and removed without reordering",
+			a:        results("m1", "m2", "m3"),
+			b:        results("m4", "m1", "m3"),
+			expected: SearchDiff{Added: []string{"m4"}, Removed: []string{"m2"}},
+		},
+		{
+			name:     "empty",
+			a:        nil,
+			b:        results("m1"),
+			expected: SearchDiff{Added: []string{"m1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffSearchResults(tt.a, tt.b)
+			if !reflect.DeepEqual(diff, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, diff)
+			}
+			if changed := !reflect.DeepEqual(tt.expected, SearchDiff{}); diff.Changed() != changed {
+				t.Errorf("expected Changed to be %v", changed)
+			}
+		})
+	}
+}